@@ -0,0 +1,147 @@
+package tracer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Thresholds of anomaly detectors (Config.EnableAlarms)
+const (
+	alarmSQLRateFactor  = 10
+	alarmRouteP99Factor = 10
+	alarmErrorRate      = 0.05
+	alarmGoroutines     = 10000
+	// alarmMinSamples is minimum number of requests (or measurements in window) to check rates and percentiles
+	alarmMinSamples = 100
+)
+
+var routeErrorCount int64
+
+func countRouteError() {
+	atomic.AddInt64(&routeErrorCount, 1)
+}
+
+// alarmBaseline is made from summary.json of the previous trace
+type alarmBaseline struct {
+	sqlRate  float64 // queries per second
+	routeP99 map[string]time.Duration
+}
+
+type alarmChecker struct {
+	file     *os.File
+	baseline alarmBaseline
+	fired    map[string]bool
+	lastSQL  int64
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+var alarms *alarmChecker
+
+func readAlarmBaseline(fileName string) alarmBaseline {
+	b := alarmBaseline{routeP99: map[string]time.Duration{}}
+	s, err := readSummary(fileName)
+	if err != nil {
+		return b
+	}
+	if d := s.EndTime.Sub(s.StartTime).Seconds(); d > 0 {
+		b.sqlRate = float64(s.SQLCount) / d
+	}
+	for _, r := range s.Routes {
+		b.routeP99[r.Tag] = time.Duration(r.P99Ns)
+	}
+	return b
+}
+
+// startAlarms check anomalies every second and write them to alarms-{TraceID}.log (Config.EnableAlarms)
+// Baseline is summary.json of the previous trace, so it must be called before the summary is overwritten.
+func startAlarms(traceID string, dirName string) error {
+	atomic.StoreInt64(&routeErrorCount, 0)
+	if !config.EnableAlarms {
+		return nil
+	}
+	file, err := os.Create(path.Join(dirName, "alarms-"+traceID+".log"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(file, "# time\ttype\tthreshold\tobserved")
+	a := &alarmChecker{
+		file:     file,
+		baseline: readAlarmBaseline(config.summaryPath()),
+		fired:    map[string]bool{},
+		done:     make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.done:
+				return
+			case <-ticker.C:
+				a.check()
+			}
+		}
+	}()
+	alarms = a
+	return nil
+}
+
+func stopAlarms() error {
+	if alarms == nil {
+		return nil
+	}
+	close(alarms.done)
+	alarms.wg.Wait()
+	err := alarms.file.Close()
+	alarms = nil
+	return err
+}
+
+// fire write alarm once per type in a trace
+func (a *alarmChecker) fire(alarmType string, threshold string, observed string) {
+	if a.fired[alarmType] {
+		return
+	}
+	a.fired[alarmType] = true
+	fmt.Fprintf(a.file, "%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), alarmType, threshold, observed)
+}
+
+func (a *alarmChecker) check() {
+	count := atomic.LoadInt64(&sqlCount)
+	rate := float64(count - a.lastSQL)
+	a.lastSQL = count
+	if a.baseline.sqlRate > 0 && rate > a.baseline.sqlRate*alarmSQLRateFactor {
+		a.fire("sql_rate", fmt.Sprintf("%.1f/s", a.baseline.sqlRate*alarmSQLRateFactor), fmt.Sprintf("%.1f/s", rate))
+	}
+
+	routeWindows.Range(func(key, value interface{}) bool {
+		tag := key.(string)
+		w := value.(*SlidingWindow)
+		base := a.baseline.routeP99[tag]
+		if base <= 0 || w.Len() < alarmMinSamples {
+			return true
+		}
+		if p99 := w.P99(); p99 > base*alarmRouteP99Factor {
+			a.fire("route_p99:"+tag, (base * alarmRouteP99Factor).String(), p99.String())
+		}
+		return true
+	})
+
+	if routes := atomic.LoadInt64(&routeCount); routes >= alarmMinSamples {
+		if errorRate := float64(atomic.LoadInt64(&routeErrorCount)) / float64(routes); errorRate > alarmErrorRate {
+			a.fire("error_rate", fmt.Sprintf("%.1f%%", alarmErrorRate*100), fmt.Sprintf("%.1f%%", errorRate*100))
+		}
+	}
+
+	if n := runtime.NumGoroutine(); n > alarmGoroutines {
+		a.fire("goroutines", fmt.Sprint(alarmGoroutines), fmt.Sprint(n))
+	}
+}
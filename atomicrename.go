@@ -0,0 +1,40 @@
+package tracer
+
+import (
+	"os"
+	"sync"
+)
+
+const tempLogSuffix = ".tmp"
+
+var tempLogs sync.Map // *os.File -> final file name
+
+// createTempLog create {fileName}.tmp instead of fileName if Config.AtomicRename is set
+func createTempLog(fileName string) (*os.File, error) {
+	if !config.AtomicRename {
+		return os.Create(fileName)
+	}
+	file, err := os.Create(fileName + tempLogSuffix)
+	if err != nil {
+		return nil, err
+	}
+	tempLogs.Store(file, fileName)
+	return file, nil
+}
+
+// closeTempLog sync and close {fileName}.tmp, then rename it to fileName atomically
+func closeTempLog(file *os.File) error {
+	fileName, ok := tempLogs.Load(file)
+	if !ok {
+		return file.Close()
+	}
+	tempLogs.Delete(file)
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fileName.(string)+tempLogSuffix, fileName.(string))
+}
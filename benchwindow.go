@@ -0,0 +1,66 @@
+package tracer
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const benchmarkRateWindow = 5 // seconds
+const benchmarkEndDuration = 10 * time.Second
+
+var requestCounter int64
+var benchmarkWindowOnce sync.Once
+
+func countRequest() {
+	atomic.AddInt64(&requestCounter, 1)
+}
+
+// uncountRequest cancel countRequest of request excluded after routing
+func uncountRequest() {
+	atomic.AddInt64(&requestCounter, -1)
+}
+
+// startBenchmarkWindowDetector Start Tracer when request rate exceeds Config.BenchmarkStartRate,
+// and Stop it when request rate stays below Config.BenchmarkEndRate for 10 seconds
+func startBenchmarkWindowDetector() {
+	benchmarkWindowOnce.Do(func() {
+		go func() {
+			var counts [benchmarkRateWindow]int64
+			var autoStarted bool
+			var lowSince time.Time
+			for i := 0; ; i++ {
+				time.Sleep(time.Second)
+				counts[i%benchmarkRateWindow] = atomic.SwapInt64(&requestCounter, 0)
+				var total int64
+				for _, c := range counts {
+					total += c
+				}
+				rate := float64(total) / benchmarkRateWindow
+
+				if TraceID == "" {
+					autoStarted = false
+					if config.BenchmarkStartRate > 0 && rate > config.BenchmarkStartRate {
+						log.Printf("ISUCON Tracer Benchmark Detected (%.1f req/s)\n", rate)
+						logError(Start())
+						autoStarted = true
+						lowSince = time.Time{}
+					}
+					continue
+				}
+				if !autoStarted || rate >= config.BenchmarkEndRate {
+					lowSince = time.Time{}
+					continue
+				}
+				if lowSince.IsZero() {
+					lowSince = time.Now()
+				} else if time.Since(lowSince) > benchmarkEndDuration {
+					log.Printf("ISUCON Tracer Benchmark End Detected (%.1f req/s)\n", rate)
+					logError(Stop())
+					autoStarted = false
+				}
+			}
+		}()
+	})
+}
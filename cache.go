@@ -0,0 +1,82 @@
+package tracer
+
+import (
+	"sync"
+)
+
+var cacheLogFile *LogFile
+var cacheLogFileOnce sync.Once
+
+type cacheCount struct {
+	hit   int64
+	total int64
+}
+
+var cacheCountsMutex sync.Mutex
+var cacheCounts = map[string]*cacheCount{}
+
+// CacheHandle is Cache Operation Measure Handle
+type CacheHandle struct {
+	startTime int64
+	tag       string
+	key       string
+	hit       bool
+}
+
+// SetHit set whether the operation hit the cache (known after the operation)
+func (p *CacheHandle) SetHit(hit bool) {
+	p.hit = hit
+}
+
+// End is Function called when Cache Operation End
+// Write cache.log (start_ns, duration_ns, tag, key, hit) and count hit rate
+func (p *CacheHandle) End() {
+	if TraceID == "" {
+		return
+	}
+	timeDelta := now().UnixNano() - p.startTime
+	cacheLogFile.Printf("%d\t%d\t%s\t%s\t%t\n", p.startTime, timeDelta, p.tag, p.key, p.hit)
+
+	cacheCountsMutex.Lock()
+	defer cacheCountsMutex.Unlock()
+	c, ok := cacheCounts[p.tag]
+	if !ok {
+		c = &cacheCount{}
+		cacheCounts[p.tag] = c
+	}
+	c.total++
+	if p.hit {
+		c.hit++
+	}
+}
+
+// CacheMeasure make create New Cache Operation Measure Handle
+// Create it before the operation, and call End right after it (hit can be set later by SetHit).
+//
+//	p := tracer.CacheMeasure("user", key, false)
+//	v, ok := cache.Get(key)
+//	p.SetHit(ok)
+//	p.End()
+func CacheMeasure(tag string, key string, hit bool) CacheHandle {
+	cacheLogFileOnce.Do(func() {
+		cacheLogFile = NewLogFile("cache")
+	})
+	return CacheHandle{startTime: now().UnixNano(), tag: tag, key: key, hit: hit}
+}
+
+// CacheHitRate return cache hit rate of tag in current trace (0 if no operation)
+func CacheHitRate(tag string) float64 {
+	cacheCountsMutex.Lock()
+	defer cacheCountsMutex.Unlock()
+	c, ok := cacheCounts[tag]
+	if !ok || c.total == 0 {
+		return 0
+	}
+	return float64(c.hit) / float64(c.total)
+}
+
+func resetCacheCounts() {
+	cacheCountsMutex.Lock()
+	defer cacheCountsMutex.Unlock()
+	cacheCounts = map[string]*cacheCount{}
+}
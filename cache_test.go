@@ -0,0 +1,20 @@
+package tracer
+
+import "testing"
+
+func TestCacheMeasureHitRate(t *testing.T) {
+	SetConfig(Config{LogDir: t.TempDir()})
+	defer SetConfig(Config{})
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer Stop()
+	for _, hit := range []bool{true, false, true, true} {
+		p := CacheMeasure("user", "1", false)
+		p.SetHit(hit)
+		p.End()
+	}
+	if got := CacheHitRate("user"); got != 0.75 {
+		t.Errorf("CacheHitRate() = %v, want 0.75", got)
+	}
+}
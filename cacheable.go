@@ -0,0 +1,123 @@
+package tracer
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxCacheableQueryKeys is limit of remembered query+params (all are forgotten when exceeded)
+const maxCacheableQueryKeys = 100000
+
+var regexWriteTable = regexp.MustCompile("(?i)^\\s*(?:INSERT\\s+(?:IGNORE\\s+)?INTO|REPLACE\\s+INTO|UPDATE(?:\\s+IGNORE)?|DELETE\\s+FROM)\\s+`?(\\w+)")
+var regexReadTable = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+`?(\\w+)")
+
+type cacheableCount struct {
+	count int64
+	hits  int64
+}
+
+var cacheableMutex sync.Mutex
+
+// tableGenerations count writes per table
+var tableGenerations = map[string]int64{}
+
+// queryGenerations is sum of table generations when query+params was executed last time
+var queryGenerations = map[uint64]int64{}
+var cacheableCounts = map[string]*cacheableCount{}
+
+func resetCacheableQueries() {
+	cacheableMutex.Lock()
+	defer cacheableMutex.Unlock()
+	tableGenerations = map[string]int64{}
+	queryGenerations = map[uint64]int64{}
+	cacheableCounts = map[string]*cacheableCount{}
+}
+
+func queryKey(query string, args []driver.NamedValue) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(query))
+	for _, a := range args {
+		fmt.Fprintf(h, "\x00%v", a.Value)
+	}
+	return h.Sum64()
+}
+
+// checkCacheableQuery report whether query is potentially cacheable (Config.TrackCacheableQueries)
+// Result rows can not be read by driver hooks, so a SELECT is potentially cacheable when
+// the same query and params was executed before and no table in FROM or JOIN was written since then.
+// Writes are counted only for queries through this driver.
+func checkCacheableQuery(query string, args []driver.NamedValue) bool {
+	cacheableMutex.Lock()
+	defer cacheableMutex.Unlock()
+
+	if m := regexWriteTable.FindStringSubmatch(query); m != nil {
+		tableGenerations[strings.ToLower(m[1])]++
+		return false
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+		return false
+	}
+
+	var generation int64
+	for _, m := range regexReadTable.FindAllStringSubmatch(query, -1) {
+		generation += tableGenerations[strings.ToLower(m[1])]
+	}
+	key := queryKey(query, args)
+	last, seen := queryGenerations[key]
+	if len(queryGenerations) >= maxCacheableQueryKeys {
+		queryGenerations = map[uint64]int64{}
+	}
+	queryGenerations[key] = generation
+	cacheable := seen && last == generation
+
+	fingerprint := Fingerprint(query)
+	c, ok := cacheableCounts[fingerprint]
+	if !ok {
+		c = &cacheableCount{}
+		cacheableCounts[fingerprint] = c
+	}
+	c.count++
+	if cacheable {
+		c.hits++
+	}
+	return cacheable
+}
+
+// writeCacheableQueries write fingerprints of potentially cacheable queries (hits, count, fingerprint)
+func writeCacheableQueries(fileName string) error {
+	type line struct {
+		fingerprint string
+		cacheableCount
+	}
+	cacheableMutex.Lock()
+	var lines []line
+	for fingerprint, c := range cacheableCounts {
+		if c.hits > 0 {
+			lines = append(lines, line{fingerprint, *c})
+		}
+	}
+	cacheableMutex.Unlock()
+	sort.Slice(lines, func(i, j int) bool { return lines[i].hits > lines[j].hits })
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "# hits\tcount\tfingerprint")
+	for _, l := range lines {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", l.hits, l.count, l.fingerprint)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
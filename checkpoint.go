@@ -0,0 +1,49 @@
+package tracer
+
+import (
+	"os"
+	"path"
+	"regexp"
+)
+
+var regexCheckpointLabel = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Checkpoint write statistics since Start (or the previous Checkpoint) to checkpoint-{TraceID}-{label}.json and reset them
+// Log files are synced to disk (zstd streams are flushed), and trace continues. summary.json on Stop covers only since the last Checkpoint.
+// Reset are summary counts, tag stats, route windows, SQL and DB pool peaks, DB pool wait counts, HTTP client counts,
+// heatmap, open FD count and mutex profile base (lock_hotspots). With Config.MemoryOnly no files are written, and only statistics are reset.
+//
+//	tracer.Checkpoint("after_warmup")
+func Checkpoint(label string) error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if TraceID == "" {
+		return nil
+	}
+	if err := flushSinks(); err != nil {
+		return err
+	}
+	if !config.MemoryOnly {
+		for _, file := range []*os.File{sqlLogFile, perfomanceLogFile, webrouteLogFile} {
+			if file != nil {
+				if err := syncLog(file); err != nil {
+					return err
+				}
+			}
+		}
+		label = regexCheckpointLabel.ReplaceAllString(label, "_")
+		if err := writeSummary(path.Join(config.logDir(), "checkpoint-"+TraceID+"-"+label+".json")); err != nil {
+			return err
+		}
+	}
+	resetTagStats()
+	resetRouteWindows()
+	resetSQLInFlightPeak()
+	resetHTTPClientCounts()
+	resetDBPoolStats()
+	resetHeatmap()
+	resetMutexProfileBase()
+	resetSummary(now())
+	resetFDCount()
+	return nil
+}
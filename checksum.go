@@ -0,0 +1,89 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"strings"
+)
+
+// checksumColumn is last column of log lines written with Config.ChecksumLines
+const checksumColumn = "crc32"
+
+// appendChecksum add CRC32 (IEEE, hex) of line as last column
+func appendChecksum(line string) string {
+	return fmt.Sprintf("%s\t%08x", line, crc32.ChecksumIEEE([]byte(line)))
+}
+
+// VerifyChecksum check CRC32 column of log line written with Config.ChecksumLines and return line without it
+func VerifyChecksum(line string) (string, bool) {
+	i := strings.LastIndexByte(line, '\t')
+	if i < 0 {
+		return line, false
+	}
+	var sum uint32
+	if _, err := fmt.Sscanf(line[i+1:], "%08x", &sum); err != nil || len(line)-i-1 != 8 {
+		return line, false
+	}
+	return line[:i], crc32.ChecksumIEEE([]byte(line[:i])) == sum
+}
+
+// LogScanner read columns of TSV log lines (header line is skipped)
+// If header has crc32 column (Config.ChecksumLines), lines with wrong checksum are skipped and counted.
+type LogScanner struct {
+	scanner  *bufio.Scanner
+	checksum bool
+	columns  []string
+	skipped  int
+}
+
+// NewLogScanner make create New LogScanner
+func NewLogScanner(r io.Reader) *LogScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	return &LogScanner{scanner: scanner}
+}
+
+// Scan advance to next valid line
+func (s *LogScanner) Scan() bool {
+	for s.scanner.Scan() {
+		line := strings.TrimPrefix(s.scanner.Text(), utf8BOM)
+		if strings.HasPrefix(line, "# ") {
+			s.checksum = strings.HasSuffix(line, "\t"+checksumColumn)
+			continue
+		}
+		if s.checksum {
+			var ok bool
+			if line, ok = VerifyChecksum(line); !ok {
+				s.skipped++
+				continue
+			}
+		}
+		s.columns = strings.Split(line, "\t")
+		return true
+	}
+	return false
+}
+
+// Columns return columns of current line
+func (s *LogScanner) Columns() []string {
+	return s.columns
+}
+
+// Skipped return count of lines skipped by wrong checksum
+func (s *LogScanner) Skipped() int {
+	return s.skipped
+}
+
+// Err return error of reader
+func (s *LogScanner) Err() error {
+	return s.scanner.Err()
+}
+
+func warnSkippedLines(fileName string, s *LogScanner) {
+	if s.Skipped() > 0 {
+		log.Printf("ISUCON Tracer Warning: skipped %d corrupted lines of %s\n", s.Skipped(), fileName)
+	}
+}
@@ -0,0 +1,58 @@
+package tracer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	line := "1\t2\tSELECT\tSELECT 1"
+	got, ok := VerifyChecksum(appendChecksum(line))
+	if !ok || got != line {
+		t.Errorf("VerifyChecksum(appendChecksum(%q)) = %q, %v, want %q, true", line, got, ok, line)
+	}
+
+	for _, bad := range []string{
+		line,
+		line + "\tzzzzzzzz",
+		line + "\t0000000",
+		strings.Replace(appendChecksum(line), "SELECT 1", "SELECT 2", 1),
+	} {
+		if _, ok := VerifyChecksum(bad); ok {
+			t.Errorf("VerifyChecksum(%q) = true, want false", bad)
+		}
+	}
+}
+
+func TestLogScanner(t *testing.T) {
+	input := "# start_ns\tduration_ns\tcrc32\n" +
+		appendChecksum("1\t10") + "\n" +
+		"2\t20\t00000000\n" +
+		appendChecksum("3\t30") + "\n"
+	s := NewLogScanner(strings.NewReader(input))
+	var got [][]string
+	for s.Scan() {
+		got = append(got, s.Columns())
+	}
+	want := [][]string{{"1", "10"}, {"3", "30"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns = %v, want %v", got, want)
+	}
+	if s.Skipped() != 1 {
+		t.Errorf("Skipped() = %d, want 1", s.Skipped())
+	}
+}
+
+func TestLogScannerWithoutChecksum(t *testing.T) {
+	input := utf8BOM + "# start_ns\tduration_ns\n1\t10\n2\t20\n"
+	s := NewLogScanner(strings.NewReader(input))
+	var got [][]string
+	for s.Scan() {
+		got = append(got, s.Columns())
+	}
+	want := [][]string{{"1", "10"}, {"2", "20"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+// Package chitracer is chi Router Middleware for ISUCON Tracer
+//
+//	r := chi.NewRouter()
+//	r.Use(chitracer.Middleware)
+package chitracer
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+// Middleware measure each request into webroute.log
+// Tag is the route pattern (e.g. "/users/{id}") resolved by chi, otherwise the URL path.
+// URL parameters are written to params column (e.g. "id=1").
+// Pattern is resolved by Routes.Match before the handler for route concurrency.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := tracer.WebRouteMeasure(matchPattern(r), r.Method)
+		next.ServeHTTP(w, r)
+		// RoutePattern is complete after sub-routers have routed the request
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				p.SetTag(pattern)
+			}
+			if len(rctx.URLParams.Keys) > 0 {
+				values := url.Values{}
+				for i, key := range rctx.URLParams.Keys {
+					// empty "*" is added by mounted sub-routers
+					if key == "*" && rctx.URLParams.Values[i] == "" {
+						continue
+					}
+					values.Add(key, rctx.URLParams.Values[i])
+				}
+				p.SetParams(values.Encode())
+			}
+		}
+		p.End()
+	})
+}
+
+// matchPattern return route pattern matched by the router running this Middleware, otherwise the URL path
+func matchPattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+		tctx := chi.NewRouteContext()
+		if rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+			if pattern := tctx.RoutePattern(); pattern != "" {
+				return pattern
+			}
+		}
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,192 @@
+// Package clickhousetracer is ClickHouse Sink for ISUCON Tracer
+//
+//	sink, err := clickhousetracer.NewClickHouseSink("clickhouse://127.0.0.1:9000/isucon")
+//	tracer.SetConfig(tracer.Config{Sinks: []tracer.Sink{sink}})
+//
+// Tables in schema.sql are created if they don't exist.
+package clickhousetracer
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+const batchSize = 1000
+
+//go:embed schema.sql
+var schema string
+
+type clickHouseSink struct {
+	conn driver.Conn
+
+	mutex  sync.Mutex
+	sqls   []tracer.SQLEntry
+	perfs  []tracer.PerfEntry
+	routes []tracer.RouteEntry
+
+	// sending is full batches being inserted in background (waited by Flush)
+	sending sync.WaitGroup
+}
+
+// NewClickHouseSink make create New Sink inserting measurements into ClickHouse (native protocol)
+// Measurements are inserted by batches of 1000 rows per table.
+// Flush (called on Stop) inserts buffered measurements and waits for batches being inserted.
+// Returned Sink implements io.Closer, Close flushes and closes the connection.
+func NewClickHouseSink(dsn string) (tracer.Sink, error) {
+	opt, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := clickhouse.Open(opt)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	for _, statement := range strings.Split(schema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := conn.Exec(ctx, statement); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &clickHouseSink{conn: conn}, nil
+}
+
+func (s *clickHouseSink) WriteSQL(e tracer.SQLEntry) {
+	s.mutex.Lock()
+	s.sqls = append(s.sqls, e)
+	if len(s.sqls) < batchSize {
+		s.mutex.Unlock()
+		return
+	}
+	entries := s.sqls
+	s.sqls = nil
+	s.mutex.Unlock()
+	traceID := tracer.TraceID
+	s.send(func() error { return s.insertSQL(traceID, entries) })
+}
+
+func (s *clickHouseSink) WritePerf(e tracer.PerfEntry) {
+	s.mutex.Lock()
+	s.perfs = append(s.perfs, e)
+	if len(s.perfs) < batchSize {
+		s.mutex.Unlock()
+		return
+	}
+	entries := s.perfs
+	s.perfs = nil
+	s.mutex.Unlock()
+	traceID := tracer.TraceID
+	s.send(func() error { return s.insertPerf(traceID, entries) })
+}
+
+func (s *clickHouseSink) WriteRoute(e tracer.RouteEntry) {
+	s.mutex.Lock()
+	s.routes = append(s.routes, e)
+	if len(s.routes) < batchSize {
+		s.mutex.Unlock()
+		return
+	}
+	entries := s.routes
+	s.routes = nil
+	s.mutex.Unlock()
+	traceID := tracer.TraceID
+	s.send(func() error { return s.insertRoute(traceID, entries) })
+}
+
+// send insert full batch in background
+func (s *clickHouseSink) send(insert func() error) {
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		logError(insert())
+	}()
+}
+
+// Flush insert buffered measurements and wait for batches being inserted in background
+func (s *clickHouseSink) Flush() error {
+	s.mutex.Lock()
+	sqls, perfs, routes := s.sqls, s.perfs, s.routes
+	s.sqls, s.perfs, s.routes = nil, nil, nil
+	s.mutex.Unlock()
+	err := errors.Join(
+		s.insertSQL(tracer.TraceID, sqls),
+		s.insertPerf(tracer.TraceID, perfs),
+		s.insertRoute(tracer.TraceID, routes),
+	)
+	s.sending.Wait()
+	return err
+}
+
+// Close flush measurements and close the connection
+func (s *clickHouseSink) Close() error {
+	return errors.Join(s.Flush(), s.conn.Close())
+}
+
+func logError(err error) {
+	if err != nil {
+		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
+	}
+}
+
+func (s *clickHouseSink) insertSQL(traceID string, entries []tracer.SQLEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch, err := s.conn.PrepareBatch(context.Background(), "INSERT INTO tracer_sql")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := batch.Append(traceID, time.Unix(0, e.StartTime), int64(e.Duration), e.Tag, e.Query, e.PerfTag); err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+func (s *clickHouseSink) insertPerf(traceID string, entries []tracer.PerfEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch, err := s.conn.PrepareBatch(context.Background(), "INSERT INTO tracer_perf")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := batch.Append(traceID, time.Unix(0, e.StartTime), int64(e.Duration), e.Tag, e.Text, e.Caller); err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+func (s *clickHouseSink) insertRoute(traceID string, entries []tracer.RouteEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch, err := s.conn.PrepareBatch(context.Background(), "INSERT INTO tracer_route")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := batch.Append(traceID, time.Unix(0, e.StartTime), int64(e.Duration), e.Tag, e.Text, e.Cacheable, e.CacheHit, e.Duplicate); err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
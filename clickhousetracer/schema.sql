@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS tracer_sql (
+    trace_id String,
+    start DateTime64(9),
+    duration_ns Int64,
+    tag LowCardinality(String),
+    query String,
+    perf_tag LowCardinality(String)
+) ENGINE = MergeTree ORDER BY (trace_id, start);
+
+CREATE TABLE IF NOT EXISTS tracer_perf (
+    trace_id String,
+    start DateTime64(9),
+    duration_ns Int64,
+    tag LowCardinality(String),
+    text String,
+    caller String
+) ENGINE = MergeTree ORDER BY (trace_id, start);
+
+CREATE TABLE IF NOT EXISTS tracer_route (
+    trace_id String,
+    start DateTime64(9),
+    duration_ns Int64,
+    tag LowCardinality(String),
+    text String,
+    cacheable Bool,
+    cache_hit Bool,
+    duplicate Bool
+) ENGINE = MergeTree ORDER BY (trace_id, start);
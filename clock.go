@@ -0,0 +1,22 @@
+package tracer
+
+import "time"
+
+// Clock is time source of measurements (Config.Clock)
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// now return current time of Config.Clock (default: time.Now)
+func now() time.Time {
+	if config.Clock != nil {
+		return config.Clock.Now()
+	}
+	return realClock{}.Now()
+}
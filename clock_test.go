@@ -0,0 +1,43 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func TestEndDurationWithClock(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	SetConfig(Config{MemoryOnly: true, Clock: clock})
+	defer SetConfig(Config{})
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer Stop()
+
+	p := Measure("getUser", "id=1")
+	clock.t = clock.t.Add(150 * time.Millisecond)
+	p.End()
+
+	entries := RecentPerf()
+	if len(entries) != 1 {
+		t.Fatalf("len(RecentPerf()) = %d, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.StartTime != time.Unix(1700000000, 0).UnixNano() {
+		t.Errorf("StartTime = %d, want %d", e.StartTime, time.Unix(1700000000, 0).UnixNano())
+	}
+	if e.Duration != 150*time.Millisecond {
+		t.Errorf("Duration = %v, want %v", e.Duration, 150*time.Millisecond)
+	}
+	if e.Tag != "getUser" || e.Text != "id=1" {
+		t.Errorf("Tag, Text = %q, %q, want %q, %q", e.Tag, e.Text, "getUser", "id=1")
+	}
+}
@@ -0,0 +1,104 @@
+// tracer-analyze summarize sql.log, perf.log and webroute.log written by ISUCON Tracer
+//
+//	tracer-analyze -top 20 /tmp/sql.log /tmp/webroute.log
+//
+// Lines are grouped by query fingerprint (sql.log) or tag (other logs) and sorted by total duration.
+// Lines with wrong CRC32 checksum (Config.ChecksumLines) are skipped and counted.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+type tagStat struct {
+	key   string
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+// analyze read TSV log file (start_ns, duration_ns, tag, text, ...) and return stats and skipped line count
+func analyze(fileName string) ([]*tagStat, int, error) {
+	file, err := tracer.OpenLog(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	isSQL := strings.HasPrefix(path.Base(fileName), "sql")
+	stats := map[string]*tagStat{}
+	scanner := tracer.NewLogScanner(file)
+	for scanner.Scan() {
+		columns := scanner.Columns()
+		if len(columns) < 4 || columns[2] == "MARK" {
+			continue
+		}
+		duration, err := strconv.ParseInt(columns[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		key := columns[2]
+		if isSQL {
+			key = tracer.Fingerprint(columns[3])
+		}
+		s, ok := stats[key]
+		if !ok {
+			s = &tagStat{key: key}
+			stats[key] = s
+		}
+		s.count++
+		s.total += time.Duration(duration)
+		if time.Duration(duration) > s.max {
+			s.max = time.Duration(duration)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]*tagStat, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].total > list[j].total })
+	return list, scanner.Skipped(), nil
+}
+
+func main() {
+	top := flag.Int("top", 20, "number of tags printed per file (0 is all)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] log...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, fileName := range flag.Args() {
+		stats, skipped, err := analyze(fileName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("## %s (skipped corrupted lines: %d)\n", fileName, skipped)
+		fmt.Printf("%8s\t%12s\t%10s\t%10s\t%s\n", "count", "total(ms)", "mean(ms)", "max(ms)", "tag")
+		for i, s := range stats {
+			if *top > 0 && i >= *top {
+				break
+			}
+			fmt.Printf("%8d\t%12.3f\t%10.3f\t%10.3f\t%s\n", s.count, float64(s.total)/1e6, float64(s.total)/float64(s.count)/1e6, float64(s.max)/1e6, s.key)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,54 @@
+// tracer-decompress write log files compressed by ISUCON Tracer (Config.CompressLogs or GzipLogs) to stdout
+//
+//	tracer-decompress /tmp/sql.log.zst | less
+//	tracer-decompress -o /tmp/sql.log /tmp/sql.log.zst
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+func decompress(w io.Writer, fileName string) error {
+	r, err := tracer.OpenLog(fileName)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func main() {
+	output := flag.String("o", "", "output file (default: stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-o output] file.log.zst|file.log.gz...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		w = file
+	}
+	for _, fileName := range flag.Args() {
+		if err := decompress(w, fileName); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
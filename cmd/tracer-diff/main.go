@@ -0,0 +1,39 @@
+// tracer-diff compare two summary.json files written by ISUCON Tracer
+//
+//	tracer-diff before/summary.json after/summary.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+func printDeltas(title string, deltas []tracer.MetricDelta) {
+	fmt.Printf("## %s (%d)\n", title, len(deltas))
+	for _, d := range deltas {
+		fmt.Printf("%+12.3f\t%12.3f -> %12.3f\t%s\n", d.Delta, d.Before, d.After, d.Metric)
+	}
+	fmt.Println()
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s before/summary.json after/summary.json\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	report, err := tracer.CompareSummaries(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	printDeltas("Improvements", report.Improvements)
+	printDeltas("Regressions", report.Regressions)
+}
@@ -0,0 +1,186 @@
+// tracer-loadgen send requests to a server at the per-route rates observed in webroute.log
+//
+//	tracer-loadgen -base-url http://127.0.0.1:8080 -scale-factor 2 -duration 60s /tmp/webroute.log
+//
+// Compressed log (webroute.log.zst or webroute.log.gz written with Config.CompressLogs or GzipLogs) is also accepted.
+//
+// Tags written by Middleware (URL path or ServeMux pattern with method in text) are requested.
+// Tags which are not paths or contain wildcards can not be requested and are skipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+var httpMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+type route struct {
+	method string
+	path   string
+	count  int64
+	rate   float64
+	sent   int64
+	errors int64
+}
+
+func readRoutes(fileName string) ([]*route, time.Duration, int, error) {
+	file, err := tracer.OpenLog(fileName)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer file.Close()
+
+	routes := map[string]*route{}
+	var first, last int64
+	scanner := tracer.NewLogScanner(file)
+	for scanner.Scan() {
+		columns := scanner.Columns()
+		if len(columns) < 4 {
+			continue
+		}
+		startTime, err := strconv.ParseInt(columns[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		method, path := columns[3], columns[2]
+		if i := strings.Index(path, " "); i >= 0 {
+			method, path = path[:i], path[i+1:]
+		}
+		if !httpMethods[method] {
+			method = http.MethodGet
+		}
+		if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, "{}") {
+			continue
+		}
+		if first == 0 || startTime < first {
+			first = startTime
+		}
+		if startTime > last {
+			last = startTime
+		}
+		key := method + " " + path
+		r, ok := routes[key]
+		if !ok {
+			r = &route{method: method, path: path}
+			routes[key] = r
+		}
+		r.count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	span := time.Duration(last - first)
+	if span < time.Second {
+		span = time.Second
+	}
+	list := make([]*route, 0, len(routes))
+	for _, r := range routes {
+		r.rate = float64(r.count) / span.Seconds()
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].count > list[j].count })
+	return list, span, scanner.Skipped(), nil
+}
+
+func main() {
+	baseURL := flag.String("base-url", "http://127.0.0.1:8080", "base URL of the server")
+	scaleFactor := flag.Float64("scale-factor", 1, "multiply observed request rates")
+	duration := flag.Duration("duration", time.Minute, "duration of load")
+	clients := flag.Int("clients", 16, "number of http.Client instances")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] webroute.log\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *scaleFactor <= 0 || *clients <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	routes, span, skipped, err := readRoutes(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("observed %d routes in %s\n", len(routes), span.Round(time.Millisecond))
+	if skipped > 0 {
+		fmt.Printf("skipped %d corrupted lines\n", skipped)
+	}
+
+	requests := make(chan *route, *clients)
+	var workers sync.WaitGroup
+	for i := 0; i < *clients; i++ {
+		workers.Add(1)
+		client := &http.Client{Timeout: 10 * time.Second}
+		go func() {
+			defer workers.Done()
+			for r := range requests {
+				req, err := http.NewRequest(r.method, strings.TrimRight(*baseURL, "/")+r.path, nil)
+				if err == nil {
+					var res *http.Response
+					if res, err = client.Do(req); err == nil {
+						io.Copy(io.Discard, res.Body)
+						res.Body.Close()
+						if res.StatusCode >= 500 {
+							atomic.AddInt64(&r.errors, 1)
+						}
+					}
+				}
+				if err != nil {
+					atomic.AddInt64(&r.errors, 1)
+				}
+			}
+		}()
+	}
+
+	deadline := time.After(*duration)
+	done := make(chan struct{})
+	var tickers sync.WaitGroup
+	for _, r := range routes {
+		tickers.Add(1)
+		go func(r *route) {
+			defer tickers.Done()
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / (r.rate * *scaleFactor)))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					select {
+					case requests <- r:
+						atomic.AddInt64(&r.sent, 1)
+					case <-done:
+						return
+					}
+				}
+			}
+		}(r)
+	}
+	<-deadline
+	close(done)
+	tickers.Wait()
+	close(requests)
+	workers.Wait()
+
+	fmt.Printf("%10s\t%10s\t%8s\t%s\n", "rate", "sent", "errors", "route")
+	for _, r := range routes {
+		fmt.Printf("%10.2f\t%10d\t%8d\t%s %s\n", r.rate**scaleFactor, r.sent, r.errors, r.method, r.path)
+	}
+}
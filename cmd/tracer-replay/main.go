@@ -0,0 +1,119 @@
+// tracer-replay replay queries of sql.log against a database and compare execution times
+//
+//	tracer-replay -dsn 'isucon:isucon@tcp(127.0.0.1:3306)/isucon' -rate 200 /tmp/sql.log
+//
+// Bind parameters are not written to sql.log, so queries with placeholders are skipped.
+// Only SELECT queries are replayed unless -write is set.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+type replayStat struct {
+	fingerprint string
+	count       int
+	logged      time.Duration
+	replayed    time.Duration
+	errors      int
+}
+
+func main() {
+	driverName := flag.String("driver", "mysql", "database/sql driver name")
+	dsn := flag.String("dsn", "", "data source name")
+	rate := flag.Float64("rate", 100, "queries per second (0 is unlimited)")
+	write := flag.Bool("write", false, "replay non-SELECT queries too")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] sql.log\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	entries, err := tracer.ReadSQLLog(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	db, err := sql.Open(*driverName, *dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	var interval time.Duration
+	if *rate > 0 {
+		interval = time.Duration(float64(time.Second) / *rate)
+	}
+
+	stats := map[string]*replayStat{}
+	skipped := 0
+	for _, e := range entries {
+		isSelect := strings.HasPrefix(strings.ToUpper(strings.TrimSpace(e.Query)), "SELECT")
+		if strings.Contains(e.Query, "?") || (!isSelect && !*write) {
+			skipped++
+			continue
+		}
+		fingerprint := tracer.Fingerprint(e.Query)
+		s, ok := stats[fingerprint]
+		if !ok {
+			s = &replayStat{fingerprint: fingerprint}
+			stats[fingerprint] = s
+		}
+
+		startTime := time.Now()
+		if isSelect {
+			var rows *sql.Rows
+			if rows, err = db.Query(e.Query); err == nil {
+				for rows.Next() {
+				}
+				err = rows.Close()
+			}
+		} else {
+			_, err = db.Exec(e.Query)
+		}
+		replayed := time.Since(startTime)
+		if err != nil {
+			s.errors++
+		} else {
+			s.count++
+			s.logged += e.Duration
+			s.replayed += replayed
+		}
+		if interval > replayed {
+			time.Sleep(interval - replayed)
+		}
+	}
+
+	list := make([]*replayStat, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].logged > list[j].logged })
+
+	fmt.Printf("replayed %d fingerprints, skipped %d queries\n", len(list), skipped)
+	fmt.Printf("%8s\t%8s\t%12s\t%12s\t%8s\t%s\n", "count", "errors", "logged_ms", "replay_ms", "change", "fingerprint")
+	for _, s := range list {
+		if s.count == 0 {
+			fmt.Printf("%8d\t%8d\t%12s\t%12s\t%8s\t%s\n", s.count, s.errors, "-", "-", "-", s.fingerprint)
+			continue
+		}
+		loggedMean := float64(s.logged) / float64(s.count) / 1e6
+		replayedMean := float64(s.replayed) / float64(s.count) / 1e6
+		change := (replayedMean - loggedMean) / loggedMean * 100
+		fmt.Printf("%8d\t%8d\t%12.3f\t%12.3f\t%+7.1f%%\t%s\n", s.count, s.errors, loggedMean, replayedMean, change, s.fingerprint)
+	}
+}
@@ -0,0 +1,98 @@
+package tracer
+
+import "sort"
+
+// MetricDelta is difference of a metric between two summaries
+type MetricDelta struct {
+	Metric string
+	Before float64
+	After  float64
+	Delta  float64
+}
+
+// ComparisonReport is result of CompareSummaries
+type ComparisonReport struct {
+	Improvements []MetricDelta
+	Regressions  []MetricDelta
+}
+
+func (r *ComparisonReport) add(metric string, before float64, after float64, lowerIsBetter bool) {
+	if before == after {
+		return
+	}
+	d := MetricDelta{Metric: metric, Before: before, After: after, Delta: after - before}
+	if (d.Delta < 0) == lowerIsBetter {
+		r.Improvements = append(r.Improvements, d)
+	} else {
+		r.Regressions = append(r.Regressions, d)
+	}
+}
+
+// CompareSummaries compare two summary.json files
+// Fewer SQL queries, more route requests and lower mean latencies are improvements.
+// Mean latencies (ms) are compared only for routes and query fingerprints in both summaries.
+func CompareSummaries(before string, after string) (ComparisonReport, error) {
+	var report ComparisonReport
+	b, err := readSummary(before)
+	if err != nil {
+		return report, err
+	}
+	a, err := readSummary(after)
+	if err != nil {
+		return report, err
+	}
+
+	report.add("sql_count", float64(b.SQLCount), float64(a.SQLCount), true)
+	report.add("route_count", float64(b.RouteCount), float64(a.RouteCount), false)
+
+	tags := map[string]bool{}
+	beforeRoutes := map[string]routeSummary{}
+	for _, r := range b.Routes {
+		beforeRoutes[r.Tag] = r
+		tags[r.Tag] = true
+	}
+	afterRoutes := map[string]routeSummary{}
+	for _, r := range a.Routes {
+		afterRoutes[r.Tag] = r
+		tags[r.Tag] = true
+	}
+	for _, tag := range sortedKeys(tags) {
+		br, inBefore := beforeRoutes[tag]
+		ar, inAfter := afterRoutes[tag]
+		report.add("route_count:"+tag, float64(br.Count), float64(ar.Count), false)
+		if inBefore && inAfter {
+			report.add("route_mean_ms:"+tag, float64(br.MeanNs)/1e6, float64(ar.MeanNs)/1e6, true)
+		}
+	}
+
+	fingerprints := map[string]bool{}
+	beforeQueries := map[string]querySummary{}
+	for _, q := range b.Queries {
+		beforeQueries[q.Fingerprint] = q
+		fingerprints[q.Fingerprint] = true
+	}
+	afterQueries := map[string]querySummary{}
+	for _, q := range a.Queries {
+		afterQueries[q.Fingerprint] = q
+		fingerprints[q.Fingerprint] = true
+	}
+	for _, fingerprint := range sortedKeys(fingerprints) {
+		bq, inBefore := beforeQueries[fingerprint]
+		aq, inAfter := afterQueries[fingerprint]
+		report.add("query_count:"+fingerprint, float64(bq.Count), float64(aq.Count), true)
+		if inBefore && inAfter {
+			report.add("query_mean_ms:"+fingerprint, float64(bq.MeanNs)/1e6, float64(aq.MeanNs)/1e6, true)
+		}
+	}
+
+	return report, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
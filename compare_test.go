@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"io/ioutil"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestCompareSummaries(t *testing.T) {
+	dir := t.TempDir()
+	before := path.Join(dir, "before.json")
+	after := path.Join(dir, "after.json")
+	if err := ioutil.WriteFile(before, []byte(`{
+		"sql_count": 100, "route_count": 10,
+		"routes": [{"tag": "/users", "count": 10, "mean_ns": 2000000}, {"tag": "/old", "count": 1, "mean_ns": 1000000}],
+		"queries": [{"fingerprint": "SELECT ?", "count": 100, "mean_ns": 1000000}]
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(after, []byte(`{
+		"sql_count": 20, "route_count": 12,
+		"routes": [{"tag": "/users", "count": 12, "mean_ns": 3000000}],
+		"queries": [{"fingerprint": "SELECT ?", "count": 20, "mean_ns": 1000000}]
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := CompareSummaries(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantImprovements := []MetricDelta{
+		{Metric: "sql_count", Before: 100, After: 20, Delta: -80},
+		{Metric: "route_count", Before: 10, After: 12, Delta: 2},
+		{Metric: "route_count:/users", Before: 10, After: 12, Delta: 2},
+		{Metric: "query_count:SELECT ?", Before: 100, After: 20, Delta: -80},
+	}
+	wantRegressions := []MetricDelta{
+		{Metric: "route_count:/old", Before: 1, After: 0, Delta: -1},
+		{Metric: "route_mean_ms:/users", Before: 2, After: 3, Delta: 1},
+	}
+	if !reflect.DeepEqual(report.Improvements, wantImprovements) {
+		t.Errorf("Improvements = %+v, want %+v", report.Improvements, wantImprovements)
+	}
+	if !reflect.DeepEqual(report.Regressions, wantRegressions) {
+		t.Errorf("Regressions = %+v, want %+v", report.Regressions, wantRegressions)
+	}
+}
+
+func TestCompareSummariesMissingFile(t *testing.T) {
+	if _, err := CompareSummaries(path.Join(t.TempDir(), "none.json"), path.Join(t.TempDir(), "none.json")); err == nil {
+		t.Error("CompareSummaries of missing files returned nil error")
+	}
+}
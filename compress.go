@@ -0,0 +1,166 @@
+package tracer
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressedLogSuffix = ".zst"
+const gzipLogSuffix = ".gz"
+
+// streamWriter is implemented by zstd.Encoder and gzip.Writer
+type streamWriter interface {
+	io.Writer
+	Flush() error
+	Close() error
+}
+
+// compressedLog is zstd (Config.CompressLogs) or gzip (Config.GzipLogs) stream of log file
+type compressedLog struct {
+	mu           sync.Mutex
+	enc          streamWriter
+	flushOnWrite bool
+}
+
+func (c *compressedLog) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, err := c.enc.Write(p)
+	if err == nil && c.flushOnWrite {
+		err = c.enc.Flush()
+	}
+	return n, err
+}
+
+func (c *compressedLog) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Flush()
+}
+
+func (c *compressedLog) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Close()
+}
+
+var compressedLogs sync.Map // *os.File -> *compressedLog
+
+// compressedPath add ".zst" (Config.CompressLogs) or ".gz" (Config.GzipLogs) to log file path
+func compressedPath(fileName string) string {
+	if config.CompressLogs {
+		return fileName + compressedLogSuffix
+	}
+	if config.GzipLogs {
+		return fileName + gzipLogSuffix
+	}
+	return fileName
+}
+
+func (c *Config) gzipLevel() int {
+	if c.GzipLevel == 0 {
+		return gzip.DefaultCompression
+	}
+	return c.GzipLevel
+}
+
+// createLog create log file, wrapped in zstd stream (Config.CompressLogs) or gzip stream (Config.GzipLogs)
+// gzip stream is flushed on each write to be readable by zcat while Tracer is running.
+func createLog(fileName string) (*os.File, error) {
+	file, err := createTempLog(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var c *compressedLog
+	switch {
+	case config.CompressLogs:
+		enc, err := zstd.NewWriter(file, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		c = &compressedLog{enc: enc}
+	case config.GzipLogs:
+		enc, err := gzip.NewWriterLevel(file, config.gzipLevel())
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		c = &compressedLog{enc: enc, flushOnWrite: true}
+	}
+	if c != nil {
+		compressedLogs.Store(file, c)
+	}
+	return file, nil
+}
+
+// logWriter return writer of log file (zstd stream if compressed)
+func logWriter(file *os.File) io.Writer {
+	if c, ok := compressedLogs.Load(file); ok {
+		return c.(*compressedLog)
+	}
+	return file
+}
+
+// syncLog flush compressed stream and sync log file to disk
+func syncLog(file *os.File) error {
+	if c, ok := compressedLogs.Load(file); ok {
+		if err := c.(*compressedLog).flush(); err != nil {
+			return err
+		}
+	}
+	return file.Sync()
+}
+
+// closeLog flush and close compressed stream, then close log file (and rename it if Config.AtomicRename)
+func closeLog(file *os.File) error {
+	if c, ok := compressedLogs.Load(file); ok {
+		compressedLogs.Delete(file)
+		if err := c.(*compressedLog).close(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return closeTempLog(file)
+}
+
+type decompressedLog struct {
+	io.Reader
+	close func()
+	file  *os.File
+}
+
+func (d *decompressedLog) Close() error {
+	d.close()
+	return d.file.Close()
+}
+
+// OpenLog open log file for reading, decompressing it if the name ends with ".zst" (Config.CompressLogs) or ".gz" (Config.GzipLogs)
+func OpenLog(fileName string) (io.ReadCloser, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasSuffix(fileName, compressedLogSuffix):
+		dec, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &decompressedLog{Reader: dec, close: dec.Close, file: file}, nil
+	case strings.HasSuffix(fileName, gzipLogSuffix):
+		dec, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &decompressedLog{Reader: dec, close: func() { dec.Close() }, file: file}, nil
+	}
+	return file, nil
+}
@@ -0,0 +1,62 @@
+package tracer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RouteStat is statistics of web route measurements per tag
+type RouteStat struct {
+	// PeakConcurrency is max number of handlers executing at once (tag on WebRouteMeasure, or ServeMux pattern in Middleware)
+	PeakConcurrency int64
+}
+
+// routeConcurrency is number of executing handlers and its peak per route tag
+type routeConcurrency struct {
+	current int64
+	peak    int64
+}
+
+var routeConcurrencies sync.Map
+
+func startRouteConcurrency(tag string) {
+	v, ok := routeConcurrencies.Load(tag)
+	if !ok {
+		v, _ = routeConcurrencies.LoadOrStore(tag, &routeConcurrency{})
+	}
+	c := v.(*routeConcurrency)
+	storeMax(&c.peak, atomic.AddInt64(&c.current, 1))
+}
+
+func endRouteConcurrency(tag string) {
+	if v, ok := routeConcurrencies.Load(tag); ok {
+		atomic.AddInt64(&v.(*routeConcurrency).current, -1)
+	}
+}
+
+// moveRouteConcurrency move executing handler from tag on start (e.g. URL path) to resolved route tag
+// Counter of the old tag is removed when no handler is executing, so counters do not grow per URL.
+func moveRouteConcurrency(from string, to string) {
+	if v, ok := routeConcurrencies.Load(from); ok {
+		if atomic.AddInt64(&v.(*routeConcurrency).current, -1) == 0 {
+			routeConcurrencies.CompareAndDelete(from, v)
+		}
+	}
+	startRouteConcurrency(to)
+}
+
+// resetRouteConcurrencies reset peaks to current concurrency (handlers may be executing on Start)
+func resetRouteConcurrencies() {
+	routeConcurrencies.Range(func(key, value interface{}) bool {
+		c := value.(*routeConcurrency)
+		atomic.StoreInt64(&c.peak, atomic.LoadInt64(&c.current))
+		return true
+	})
+}
+
+func routePeakConcurrency(tag string) int64 {
+	if v, ok := routeConcurrencies.Load(tag); ok {
+		return atomic.LoadInt64(&v.(*routeConcurrency).peak)
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package tracer
+
+import (
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"time"
+)
+
+const defaultLogDir = "/tmp"
+const traceIDLayout = "20060102-150405.000000000"
+const defaultExitTimeout = 5 * time.Second
+
+// LogLevel is bitmask of log types written by Tracer
+type LogLevel int
+
+// LogLevel bits
+const (
+	SQLLog LogLevel = 1 << iota
+	PerfLog
+	RouteLog
+	SummaryLog
+	LogAll = SQLLog | PerfLog | RouteLog | SummaryLog
+)
+
+// Config is ISUCON Tracer Configuration
+type Config struct {
+	// LogLevel is log types to write (default: LogAll)
+	LogLevel LogLevel
+	// LogDir is directory of log files and profiles (default: RAM disk if AutoDetectRAMDisk is set, or /tmp)
+	LogDir string
+	// SQLLogPath is path of SQL log file (default: {LogDir}/sql.log)
+	SQLLogPath string
+	// PerfLogPath is path of perfomance log file (default: {LogDir}/perf.log)
+	PerfLogPath string
+	// RouteLogPath is path of web route log file (default: {LogDir}/webroute.log)
+	RouteLogPath string
+	// SummaryLogPath is path of summary file written on Stop (default: {LogDir}/summary.json)
+	SummaryLogPath string
+	// TraceIDGenerator generate TraceID on Start (default: nanosecond precision timestamp)
+	TraceIDGenerator func() string
+	// ExportTimeline write merged timeline-{TraceID}.tsv of SQL, perf and route logs on Stop (TSVEncoder only)
+	ExportTimeline bool
+	// QueryBlacklist are patterns of SQL queries written as "[REDACTED]" (e.g. queries by password or token)
+	QueryBlacklist []*regexp.Regexp
+	// HierarchicalPerfLog write perf-tree-{TraceID}.txt of nested measurements (MeasureContext) on Stop
+	HierarchicalPerfLog bool
+	// RecordCaller write "file:line" of Measure caller to perf.log (uses runtime.Callers, disabled by default)
+	RecordCaller bool
+	// ExcludedRoutes are route tags not measured (e.g. "/health")
+	ExcludedRoutes []string
+	// ExitTimeout is max time to Stop on exit signal (INT, TERM, QUIT) before exit with status 1 (default: 5s)
+	ExitTimeout time.Duration
+	// BenchmarkStartRate Start Tracer automatically when request rate (req/s in last 5 seconds) exceeds it (0 is disabled)
+	BenchmarkStartRate float64
+	// BenchmarkEndRate Stop automatically started Tracer when request rate stays below it for 10 seconds
+	BenchmarkEndRate float64
+	// Sinks receive measurements in addition to log files
+	Sinks []Sink
+	// MaxQueriesPerRequest is SQL query budget per request, exceeded requests are written to budget.log (0 is unlimited)
+	MaxQueriesPerRequest int
+	// OnBudgetExceeded is called when SQL queries of a request exceed MaxQueriesPerRequest
+	OnBudgetExceeded func(requestID string, count int)
+	// TrackHostLatency enable SQL latency statistics per database host (Stats().HostStats)
+	TrackHostLatency bool
+	// AlertThreshold is duration of a measurement to call OnAlert (0 is disabled)
+	AlertThreshold time.Duration
+	// OnAlert is called in new goroutine with SQLEntry, PerfEntry or RouteEntry exceeding AlertThreshold
+	OnAlert func(entry interface{})
+	// MaxInFlight is limit of measurements in-flight, exceeding measurements are not recorded (0 is unlimited)
+	MaxInFlight int
+	// OutputEncoding is encoding of log files: "utf8" (default), "utf8bom" or "ascii" (non-ASCII characters are escaped as \uXXXX)
+	OutputEncoding string
+	// AnnotateCPU write CPU utilization of the host during each measurement to perf.log (Linux only)
+	AnnotateCPU bool
+	// TrackTCPConnections write peak TCP connection counts (read every second) to summary.json (Linux only)
+	TrackTCPConnections bool
+	// AutoDetectRAMDisk use first writable tmpfs or ramfs mount point in /proc/mounts as LogDir if LogDir is empty
+	AutoDetectRAMDisk bool
+	// TrackCacheableQueries mark repeated SELECT with same params and no write to its tables in sql.log, and write cacheable_queries.log on Stop
+	TrackCacheableQueries bool
+	// Profiles are profiles written to LogDir: "cpu" (cpu.pprof) and "mutex" (mutex.pprof, lock_hotspots in summary.json) (default: cpu)
+	Profiles []string
+	// TagNormalizers rewrite tags of measurements in order before writing (e.g. PathParamNormalizer())
+	TagNormalizers []TagNormalizer
+	// EnableAlarms write anomalies (SQL rate or route p99 over 10x of previous summary.json, 5xx over 5%, goroutines over 10000) to alarms-{TraceID}.log
+	EnableAlarms bool
+	// Clock is time source of measurements (default: time.Now), inject fake Clock to test timing deterministically
+	Clock Clock
+	// GoroutineLocalContext store tag of MeasureContext per goroutine ID, so SQL queries without its Context get perf_tag (costs runtime.Stack per query)
+	GoroutineLocalContext bool
+	// TraceDNS write DNS resolution time and resolved IP of new connections to httpclient.log (NewTracingTransport)
+	TraceDNS bool
+	// GoroutineSampling write goroutine stack traces at peak goroutine count to goroutines-peak-{TraceID}.txt on Stop
+	GoroutineSampling bool
+	// SamplingInterval is interval of goroutine count sampling (default: 100ms)
+	SamplingInterval time.Duration
+	// ThrottleRules delay matching SQL queries before execution (even if Tracer is not running) to test behavior under slow database
+	ThrottleRules []ThrottleRule
+	// DryRunSQL log SQL queries without executing them (connections opened after SetConfig return no rows and 0 rows affected)
+	DryRunSQL bool
+	// MeasureResponseBuffering write time blocked in ResponseWriter.Write and Flush to write_ns column of webroute.log (Middleware only)
+	MeasureResponseBuffering bool
+	// MemoryOnly create no files on Start and Stop, measurements are only available by RecentSQL, RecentPerf and StatusHandler
+	MemoryOnly bool
+	// ReportInterval is interval to write one-line statistics since Start to ReportWriter (0 is disabled)
+	ReportInterval time.Duration
+	// ReportWriter is destination of ReportInterval statistics (default: os.Stderr)
+	ReportWriter io.Writer
+	// MaxTraceDuration Stop Tracer automatically when this duration passed after Start (0 is unlimited)
+	MaxTraceDuration time.Duration
+	// StartSignal is signal to Start Tracer instead of SIGUSR1
+	StartSignal os.Signal
+	// StopSignal is signal to Stop Tracer instead of SIGUSR2 and SIGHUP
+	StopSignal os.Signal
+	// RotateSignal is signal to Start new trace (rotate log files) if Tracer is running (default: none)
+	RotateSignal os.Signal
+	// PropagateSignals re-send exit signals (INT, TERM, QUIT) to this process after Stop instead of exit, for graceful shutdown of the application
+	PropagateSignals bool
+	// Encoder serialize entries of sql.log, perf.log and webroute.log (default: TSVEncoder{}, also JSONEncoder{}, CSVEncoder{} and prototracer.ProtoEncoder{})
+	Encoder Encoder
+	// CSVDelimiter is field delimiter of CSVEncoder (default: ',')
+	CSVDelimiter rune
+	// CompressLogs write sql.log, perf.log and webroute.log as zstd streams named *.log.zst (read by OpenLog or cmd/tracer-decompress)
+	CompressLogs bool
+	// GzipLogs write sql.log, perf.log and webroute.log as gzip streams named *.log.gz flushed on each write (ignored if CompressLogs is set)
+	GzipLogs bool
+	// GzipLevel is compression level of GzipLogs (default: gzip.DefaultCompression)
+	GzipLevel int
+	// ChecksumLines add CRC32 (hex) of each line as last column of TSV logs, corrupted lines are skipped by readers (LogScanner, cmd/tracer-analyze)
+	ChecksumLines bool
+	// AtomicRename write sql.log, perf.log and webroute.log to *.tmp while Tracer is running, and rename them on Stop after sync
+	AtomicRename bool
+	// HeatmapExport write heatmap-{TraceID}.json of latency percentiles (p50, p75, p90, p99) per second of SQL, perf and route measurements on Stop
+	HeatmapExport bool
+	// TrackCPUAffinity write CPU core running each SQL query (getcpu) to cpu_id column of sql.log (Linux only)
+	TrackCPUAffinity bool
+}
+
+var config Config
+var excludedRoutes = map[string]bool{}
+
+// SetConfig set ISUCON Tracer Configuration
+// Call before Start (or before sending start signal)
+func SetConfig(c Config) {
+	config = c
+	excludedRoutes = map[string]bool{}
+	for _, route := range c.ExcludedRoutes {
+		excludedRoutes[route] = true
+	}
+	if c.BenchmarkStartRate > 0 {
+		startBenchmarkWindowDetector()
+	}
+	if c.TrackTCPConnections {
+		startTCPTracker()
+	}
+	notifySignals()
+}
+
+func (c *Config) traceID() string {
+	if c.TraceIDGenerator != nil {
+		return c.TraceIDGenerator()
+	}
+	return time.Now().Format(traceIDLayout)
+}
+
+func (c *Config) exitTimeout() time.Duration {
+	if c.ExitTimeout <= 0 {
+		return defaultExitTimeout
+	}
+	return c.ExitTimeout
+}
+
+func (c *Config) logEnabled(l LogLevel) bool {
+	if c.LogLevel == 0 {
+		return true
+	}
+	return c.LogLevel&l != 0
+}
+
+func (c *Config) profileEnabled(name string) bool {
+	if len(c.Profiles) == 0 {
+		return name == "cpu"
+	}
+	for _, p := range c.Profiles {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) logDir() string {
+	if c.LogDir == "" {
+		if c.AutoDetectRAMDisk && ramDiskDir != "" {
+			return ramDiskDir
+		}
+		return defaultLogDir
+	}
+	return c.LogDir
+}
+
+func (c *Config) logPath(logPath string, name string) string {
+	if logPath != "" {
+		return logPath
+	}
+	return logFilePath(c.logDir(), name)
+}
+
+func (c *Config) summaryPath() string {
+	if c.SummaryLogPath != "" {
+		return c.SummaryLogPath
+	}
+	return path.Join(c.logDir(), "summary.json")
+}
@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cpuSampleInterval is minimum interval of reading CPU times (Config.AnnotateCPU)
+const cpuSampleInterval = 10 * time.Millisecond
+
+// cpuSample is CPU times of the host in clock ticks
+type cpuSample struct {
+	total uint64
+	idle  uint64
+}
+
+var cpuSampleMutex sync.Mutex
+var lastCPUSample cpuSample
+var lastCPUSampleTime time.Time
+
+// readCPUSample return CPU times (cached for cpuSampleInterval)
+func readCPUSample() cpuSample {
+	cpuSampleMutex.Lock()
+	defer cpuSampleMutex.Unlock()
+	if now := time.Now(); now.Sub(lastCPUSampleTime) >= cpuSampleInterval {
+		if s, ok := readProcStat(); ok {
+			lastCPUSample = s
+		}
+		lastCPUSampleTime = now
+	}
+	return lastCPUSample
+}
+
+// cpuUtilization return CPU utilization (%) between two samples ("" if unknown)
+func cpuUtilization(start cpuSample, end cpuSample) string {
+	if end.total <= start.total {
+		return ""
+	}
+	busy := float64(end.total-start.total) - float64(end.idle-start.idle)
+	return strconv.FormatFloat(busy/float64(end.total-start.total)*100, 'f', 1, 64)
+}
@@ -0,0 +1,40 @@
+package tracer
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readProcStat read total and idle (idle + iowait) CPU times from "cpu" line of /proc/stat
+func readProcStat() (cpuSample, bool) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuSample{}, false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return cpuSample{}, false
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 6 || fields[0] != "cpu" {
+		return cpuSample{}, false
+	}
+	var s cpuSample
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return cpuSample{}, false
+		}
+		// guest and guest_nice are included in user and nice
+		if i < 8 {
+			s.total += v
+		}
+		if i == 3 || i == 4 {
+			s.idle += v
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package tracer
+
+func readProcStat() (cpuSample, bool) {
+	return cpuSample{}, false
+}
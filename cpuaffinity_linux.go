@@ -0,0 +1,17 @@
+package tracer
+
+import (
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// currentCPU return CPU core running this goroutine by getcpu(2) (Config.TrackCPUAffinity)
+func currentCPU() string {
+	var cpu uint32
+	if _, _, errno := unix.RawSyscall(unix.SYS_GETCPU, uintptr(unsafe.Pointer(&cpu)), 0, 0); errno != 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(cpu), 10)
+}
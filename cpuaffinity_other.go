@@ -0,0 +1,7 @@
+//go:build !linux
+
+package tracer
+
+func currentCPU() string {
+	return ""
+}
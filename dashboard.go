@@ -0,0 +1,88 @@
+package tracer
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// dashboard.html draws route chart on canvas by itself (Chart.js is not embedded)
+//
+//go:embed dashboard.html
+var dashboardFS embed.FS
+
+const dashboardTopQueries = 10
+
+// debugHandlerPrefix is mount prefix of DebugHandler used if the request is not routed by ServeMux pattern
+const debugHandlerPrefix = "/debug/tracer/"
+
+// debugHandlerName return path of request under mount prefix of DebugHandler (ServeMux pattern, or "/debug/tracer/")
+// Requests under http.StripPrefix have path without prefix.
+func debugHandlerName(r *http.Request) string {
+	prefix := debugHandlerPrefix
+	if r.Pattern != "" {
+		// pattern may be "[METHOD ][HOST]/PATH" and have wildcards
+		prefix = r.Pattern
+		if i := strings.IndexByte(prefix, '/'); i >= 0 {
+			prefix = prefix[i:]
+		}
+		if i := strings.IndexByte(prefix, '{'); i >= 0 {
+			prefix = prefix[:i]
+		}
+	}
+	if strings.HasPrefix(r.URL.Path, prefix) {
+		return strings.TrimPrefix(r.URL.Path, prefix)
+	}
+	return strings.TrimPrefix(r.URL.Path, "/")
+}
+
+// DebugHandler return http.Handler of Tracer dashboard and its API (mount on "/debug/tracer/")
+// Paths are matched exactly under the mount prefix (e.g. "/debug/tracer/x/status" is not found).
+// Mount prefix is taken from ServeMux pattern. With other routers, mount on "/debug/tracer/" or wrap with http.StripPrefix.
+//
+//	GET  /debug/tracer/dashboard  dashboard page (route p99 chart, top 10 queries, start and stop buttons)
+//	GET  /debug/tracer/status     same as StatusHandler
+//	GET  /debug/tracer/sql        top 10 query fingerprints by total time in JSON
+//	POST /debug/tracer/start      Start
+//	POST /debug/tracer/stop       Stop
+//
+//	http.Handle("/debug/tracer/", tracer.DebugHandler())
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := debugHandlerName(r)
+		switch name {
+		case "dashboard":
+			data, _ := dashboardFS.ReadFile("dashboard.html")
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(data)
+		case "status":
+			StatusHandler().ServeHTTP(w, r)
+		case "sql":
+			queries := querySummaries()
+			if len(queries) > dashboardTopQueries {
+				queries = queries[:dashboardTopQueries]
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(queries)
+		case "start", "stop":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			var err error
+			if name == "start" {
+				err = Start()
+			} else {
+				err = Stop()
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			StatusHandler().ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package tracer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHandlerPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/tracer/", DebugHandler())
+	mux.Handle("/debug/", http.StripPrefix("/debug/", DebugHandler()))
+	other := DebugHandler() // not routed by ServeMux
+	tests := []struct {
+		handler http.Handler
+		path    string
+		want    int
+	}{
+		{mux, "/admin/tracer/status", http.StatusOK},
+		{mux, "/admin/tracer/x/status", http.StatusNotFound},
+		{mux, "/admin/tracer/status/", http.StatusNotFound},
+		{mux, "/debug/status", http.StatusOK},
+		{mux, "/debug/x/status", http.StatusNotFound},
+		{other, "/debug/tracer/status", http.StatusOK},
+		{other, "/debug/tracer/x/status", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package tracer
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+type observedDB struct {
+	name string
+	db   *sql.DB
+	base sql.DBStats // stats on Start
+}
+
+var observedDBsMutex sync.Mutex
+var observedDBs []*observedDB
+
+// dbPoolPeaks is peak values of connection pools since Start (sum of DBs is not taken)
+type dbPoolPeaks struct {
+	openConnections int
+	inUse           int
+}
+
+// dbPoolObserver write stats of DBs opened by Open to dbpool-{TraceID}.log every second
+type dbPoolObserver struct {
+	file *os.File
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+var dbPool *dbPoolObserver
+var dbPoolPeaksMutex sync.Mutex
+var dbPoolPeak dbPoolPeaks
+
+// Open open traced DB (driverName + ":logger") and observe its connection pool while Tracer is running
+// Pool stats are written to dbpool-{TraceID}.log every second, and peak values to summary.json.
+//
+//	db, err := tracer.Open("mysql", dsn)
+func Open(driverName string, dataSourceName string) (*sql.DB, error) {
+	if !strings.HasSuffix(driverName, ":logger") {
+		registerProxyDriver(driverName, "")
+		driverName += ":logger"
+	}
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	observedDBsMutex.Lock()
+	defer observedDBsMutex.Unlock()
+	name := fmt.Sprintf("%s#%d", strings.TrimSuffix(driverName, ":logger"), len(observedDBs))
+	observedDBs = append(observedDBs, &observedDB{name: name, db: db, base: db.Stats()})
+	return db, nil
+}
+
+// startDBPoolObserver start observing DBs opened by Open before Start
+func startDBPoolObserver(traceID string, dirName string) error {
+	dbPoolPeaksMutex.Lock()
+	dbPoolPeak = dbPoolPeaks{}
+	dbPoolPeaksMutex.Unlock()
+	observedDBsMutex.Lock()
+	defer observedDBsMutex.Unlock()
+	if len(observedDBs) == 0 {
+		return nil
+	}
+	for _, o := range observedDBs {
+		o.base = o.db.Stats()
+	}
+	file, err := os.Create(path.Join(dirName, "dbpool-"+traceID+".log"))
+	if err != nil {
+		return err
+	}
+	fprintHeader(file, []string{"time_ns", "db", "open_connections", "in_use", "idle", "wait_count", "wait_duration_ns"})
+	o := &dbPoolObserver{file: file, done: make(chan struct{})}
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-o.done:
+				return
+			case <-ticker.C:
+				o.observe()
+			}
+		}
+	}()
+	dbPool = o
+	return nil
+}
+
+// observe write stats of each DB (wait_count and wait_duration_ns are since Start)
+func (o *dbPoolObserver) observe() {
+	observedDBsMutex.Lock()
+	defer observedDBsMutex.Unlock()
+	t := now().UnixNano()
+	for _, db := range observedDBs {
+		s := db.db.Stats()
+		waitCount := s.WaitCount - db.base.WaitCount
+		waitDuration := s.WaitDuration - db.base.WaitDuration
+		fmt.Fprintf(o.file, "%d\t%s\t%d\t%d\t%d\t%d\t%d\n", t, db.name, s.OpenConnections, s.InUse, s.Idle, waitCount, waitDuration.Nanoseconds())
+		dbPoolPeaksMutex.Lock()
+		if s.OpenConnections > dbPoolPeak.openConnections {
+			dbPoolPeak.openConnections = s.OpenConnections
+		}
+		if s.InUse > dbPoolPeak.inUse {
+			dbPoolPeak.inUse = s.InUse
+		}
+		dbPoolPeaksMutex.Unlock()
+	}
+}
+
+func stopDBPoolObserver() error {
+	if dbPool == nil {
+		return nil
+	}
+	close(dbPool.done)
+	dbPool.wg.Wait()
+	err := dbPool.file.Close()
+	dbPool = nil
+	return err
+}
+
+// resetDBPoolStats reset peak values and wait count base of DBs opened by Open (Checkpoint)
+func resetDBPoolStats() {
+	dbPoolPeaksMutex.Lock()
+	dbPoolPeak = dbPoolPeaks{}
+	dbPoolPeaksMutex.Unlock()
+	observedDBsMutex.Lock()
+	defer observedDBsMutex.Unlock()
+	for _, o := range observedDBs {
+		o.base = o.db.Stats()
+	}
+}
+
+// dbPoolWaits return total wait count and duration of DBs opened by Open since Start
+func dbPoolWaits() (int64, time.Duration) {
+	observedDBsMutex.Lock()
+	defer observedDBsMutex.Unlock()
+	var waitCount int64
+	var waitDuration time.Duration
+	for _, db := range observedDBs {
+		s := db.db.Stats()
+		waitCount += s.WaitCount - db.base.WaitCount
+		waitDuration += s.WaitDuration - db.base.WaitDuration
+	}
+	return waitCount, waitDuration
+}
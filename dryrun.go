@@ -0,0 +1,116 @@
+package tracer
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+// dryRunDriver open connections not reaching the database while Config.DryRunSQL is set
+// (driver.ErrSkip from hooks makes database/sql fall back to prepared statements, and fail with it again)
+type dryRunDriver struct {
+	base driver.Driver
+}
+
+func (d *dryRunDriver) Open(name string) (driver.Conn, error) {
+	if config.DryRunSQL {
+		return dryRunConn{}, nil
+	}
+	return d.base.Open(name)
+}
+
+func (d *dryRunDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.base.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dryRunConnector{base: c, driver: d}, nil
+	}
+	return &dryRunConnector{name: name, driver: d}, nil
+}
+
+type dryRunConnector struct {
+	base   driver.Connector
+	name   string
+	driver *dryRunDriver
+}
+
+func (c *dryRunConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if config.DryRunSQL {
+		return dryRunConn{}, nil
+	}
+	if c.base != nil {
+		return c.base.Connect(ctx)
+	}
+	return c.driver.base.Open(c.name)
+}
+
+func (c *dryRunConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dryRunConn return no rows for queries and 0 rows affected for other statements
+type dryRunConn struct{}
+
+func (dryRunConn) Prepare(query string) (driver.Stmt, error) {
+	return dryRunStmt{}, nil
+}
+
+func (dryRunConn) Close() error {
+	return nil
+}
+
+func (dryRunConn) Begin() (driver.Tx, error) {
+	return dryRunTx{}, nil
+}
+
+func (dryRunConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (dryRunConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return dryRunRows{}, nil
+}
+
+type dryRunStmt struct{}
+
+func (dryRunStmt) Close() error {
+	return nil
+}
+
+func (dryRunStmt) NumInput() int {
+	return -1
+}
+
+func (dryRunStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (dryRunStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return dryRunRows{}, nil
+}
+
+type dryRunTx struct{}
+
+func (dryRunTx) Commit() error {
+	return nil
+}
+
+func (dryRunTx) Rollback() error {
+	return nil
+}
+
+type dryRunRows struct{}
+
+func (dryRunRows) Columns() []string {
+	return nil
+}
+
+func (dryRunRows) Close() error {
+	return nil
+}
+
+func (dryRunRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
@@ -0,0 +1,82 @@
+// Package echotracer is Echo Middleware for ISUCON Tracer
+//
+//	e := echo.New()
+//	e.Use(echotracer.Middleware(), echotracer.SQLGroupMiddleware())
+package echotracer
+
+import (
+	"net/url"
+	"sync"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware make create New Echo Middleware measuring each request into webroute.log
+// Tag is the route path (c.Path(), including prefixes of groups, e.g. "/v1/users/:id") if matched, otherwise the URL path.
+// Path parameters are written to params column, and 5xx status is counted for Config.EnableAlarms.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Echo routes the request before middlewares added by e.Use, so c.Path() is the route path here
+			tag := c.Path()
+			if tag == "" {
+				tag = c.Request().URL.Path
+			}
+			p := tracer.WebRouteMeasure(tag, c.Request().Method)
+			err := next(c)
+			if err != nil {
+				// write error response by HTTPErrorHandler to get its status
+				c.Error(err)
+			}
+			if path := c.Path(); path != "" {
+				p.SetTag(path)
+			}
+			if names := c.ParamNames(); len(names) > 0 {
+				values := url.Values{}
+				for i, value := range c.ParamValues() {
+					if i < len(names) {
+						values.Add(names[i], value)
+					}
+				}
+				p.SetParams(values.Encode())
+			}
+			p.SetStatus(c.Response().Status)
+			p.End()
+			return err
+		}
+	}
+}
+
+// SQLGroupMiddleware make create New Echo Middleware setting route name to perf_tag column of SQL queries
+// SQL queries must be executed with c.Request().Context(). Route name is Route.Name
+// (handler function name by default, or set by e.GET(...).Name = "getUser"), otherwise c.Path().
+func SQLGroupMiddleware() echo.MiddlewareFunc {
+	var routeNames sync.Map
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			group := routeName(&routeNames, c)
+			if group != "" {
+				c.SetRequest(c.Request().WithContext(tracer.WithSQLGroup(c.Request().Context(), group)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// routeName return name of matched route (cached per method and path)
+func routeName(cache *sync.Map, c echo.Context) string {
+	key := c.Request().Method + " " + c.Path()
+	if name, ok := cache.Load(key); ok {
+		return name.(string)
+	}
+	name := c.Path()
+	for _, route := range c.Echo().Routes() {
+		if route.Method == c.Request().Method && route.Path == c.Path() && route.Name != "" {
+			name = route.Name
+			break
+		}
+	}
+	cache.Store(key, name)
+	return name
+}
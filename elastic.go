@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var elasticLogFile *LogFile
+var elasticLogFileOnce sync.Once
+
+type elasticTransport struct {
+	base http.RoundTripper
+}
+
+// NewElasticTransport make create New Elasticsearch HTTP Transport writing elastic.log
+// Columns: start_ns, duration_ns, method, index, operation, status
+//
+//	es, err := elasticsearch.NewClient(elasticsearch.Config{Transport: tracer.NewElasticTransport(http.DefaultTransport)})
+func NewElasticTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	elasticLogFileOnce.Do(func() {
+		elasticLogFile = NewLogFile("elastic")
+	})
+	return &elasticTransport{base: base}
+}
+
+func (t *elasticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	startTime := now().UnixNano()
+	resp, err := t.base.RoundTrip(req)
+	timeDelta := now().UnixNano() - startTime
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	index, operation := elasticOperation(req.Method, req.URL.Path)
+	elasticLogFile.Printf("%d\t%d\t%s\t%s\t%s\t%d\n", startTime, timeDelta, req.Method, index, operation, status)
+	return resp, err
+}
+
+// elasticOperation extract index name and operation type from request path
+// (e.g. "/users/_search" -> "users", "search")
+func elasticOperation(method string, urlPath string) (string, string) {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	index := ""
+	if segments[0] != "" && !strings.HasPrefix(segments[0], "_") {
+		index = segments[0]
+	}
+	for _, segment := range segments {
+		switch segment {
+		case "_bulk":
+			return index, "bulk"
+		case "_search", "_msearch":
+			return index, "search"
+		case "_doc", "_source":
+			if method == http.MethodGet || method == http.MethodHead {
+				return index, "get"
+			}
+			return index, "index"
+		}
+	}
+	return index, "other"
+}
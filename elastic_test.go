@@ -0,0 +1,28 @@
+package tracer
+
+import "testing"
+
+func TestElasticOperation(t *testing.T) {
+	tests := []struct {
+		method    string
+		path      string
+		index     string
+		operation string
+	}{
+		{"POST", "/users/_search", "users", "search"},
+		{"POST", "/_msearch", "", "search"},
+		{"POST", "/_bulk", "", "bulk"},
+		{"POST", "/users/_bulk", "users", "bulk"},
+		{"GET", "/users/_doc/1", "users", "get"},
+		{"HEAD", "/users/_source/1", "users", "get"},
+		{"PUT", "/users/_doc/1", "users", "index"},
+		{"GET", "/users", "users", "other"},
+		{"GET", "/", "", "other"},
+	}
+	for _, tt := range tests {
+		index, operation := elasticOperation(tt.method, tt.path)
+		if index != tt.index || operation != tt.operation {
+			t.Errorf("elasticOperation(%q, %q) = %q, %q, want %q, %q", tt.method, tt.path, index, operation, tt.index, tt.operation)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package tracer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const elasticAPMBatchSize = 100
+
+type elasticAPMSpan struct {
+	name      string
+	spanType  string
+	subtype   string
+	startTime int64
+	duration  time.Duration
+	statement string
+}
+
+type elasticAPMSink struct {
+	serverURL   string
+	secretToken string
+	serviceName string
+	client      *http.Client
+
+	mutex sync.Mutex
+	spans []elasticAPMSpan
+
+	// sending is full batches being sent in background (waited by Flush)
+	sending sync.WaitGroup
+}
+
+// NewElasticAPMSink make create New Sink sending measurements to Elastic APM Server (intake v2 API)
+// Measurements are sent as spans of one transaction per batch.
+// SQL queries are "db" spans, routes are "external.http" spans and perfomance measurements are "app" spans.
+func NewElasticAPMSink(serverURL string, secretToken string, serviceName string) Sink {
+	return &elasticAPMSink{
+		serverURL:   strings.TrimSuffix(serverURL, "/"),
+		secretToken: secretToken,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *elasticAPMSink) WriteSQL(e SQLEntry) {
+	s.add(elasticAPMSpan{name: e.Tag, spanType: "db", subtype: "sql", startTime: e.StartTime, duration: e.Duration, statement: e.Query})
+}
+
+func (s *elasticAPMSink) WritePerf(e PerfEntry) {
+	s.add(elasticAPMSpan{name: e.Tag, spanType: "app", startTime: e.StartTime, duration: e.Duration})
+}
+
+func (s *elasticAPMSink) WriteRoute(e RouteEntry) {
+	s.add(elasticAPMSpan{name: e.Tag, spanType: "external", subtype: "http", startTime: e.StartTime, duration: e.Duration})
+}
+
+func (s *elasticAPMSink) add(span elasticAPMSpan) {
+	s.mutex.Lock()
+	s.spans = append(s.spans, span)
+	if len(s.spans) < elasticAPMBatchSize {
+		s.mutex.Unlock()
+		return
+	}
+	spans := s.spans
+	s.spans = nil
+	s.mutex.Unlock()
+
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		if err := s.send(spans); err != nil {
+			log.Printf("ISUCON Tracer Error: %s\n", err.Error())
+		}
+	}()
+}
+
+// Flush send buffered spans and wait for batches being sent in background
+func (s *elasticAPMSink) Flush() error {
+	s.mutex.Lock()
+	spans := s.spans
+	s.spans = nil
+	s.mutex.Unlock()
+	var err error
+	if len(spans) > 0 {
+		err = s.send(spans)
+	}
+	s.sending.Wait()
+	return err
+}
+
+func newElasticAPMID(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *elasticAPMSink) send(spans []elasticAPMSpan) error {
+	traceID := newElasticAPMID(16)
+	transactionID := newElasticAPMID(8)
+	startTime := spans[0].startTime
+	endTime := spans[0].startTime + spans[0].duration.Nanoseconds()
+	for _, span := range spans {
+		if span.startTime < startTime {
+			startTime = span.startTime
+		}
+		if t := span.startTime + span.duration.Nanoseconds(); t > endTime {
+			endTime = t
+		}
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	enc.Encode(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"service": map[string]interface{}{
+				"name":  s.serviceName,
+				"agent": map[string]interface{}{"name": "go-isucon-tracer", "version": "0"},
+			},
+		},
+	})
+	enc.Encode(map[string]interface{}{
+		"transaction": map[string]interface{}{
+			"id":         transactionID,
+			"trace_id":   traceID,
+			"name":       "ISUCON Tracer " + TraceID,
+			"type":       "tracer",
+			"timestamp":  startTime / 1000,
+			"duration":   float64(endTime-startTime) / 1e6,
+			"span_count": map[string]interface{}{"started": len(spans)},
+		},
+	})
+	for _, span := range spans {
+		v := map[string]interface{}{
+			"id":             newElasticAPMID(8),
+			"trace_id":       traceID,
+			"parent_id":      transactionID,
+			"transaction_id": transactionID,
+			"name":           span.name,
+			"type":           span.spanType,
+			"timestamp":      span.startTime / 1000,
+			"duration":       float64(span.duration.Nanoseconds()) / 1e6,
+		}
+		if span.subtype != "" {
+			v["subtype"] = span.subtype
+		}
+		if span.statement != "" {
+			v["context"] = map[string]interface{}{"db": map[string]interface{}{"type": "sql", "statement": span.statement}}
+		}
+		enc.Encode(map[string]interface{}{"span": v})
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.serverURL+"/intake/v2/events", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.secretToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.secretToken)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("elastic apm intake: %s", resp.Status)
+	}
+	return nil
+}
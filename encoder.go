@@ -0,0 +1,198 @@
+package tracer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Encoder serialize entries of sql.log, perf.log and webroute.log (Config.Encoder)
+// Encoders having EncodeHeader(w io.Writer, columns []string) error method write header on Start.
+// ReadSQLLog, timeline and tools in cmd read only TSVEncoder format (error is returned with other encoders).
+type Encoder interface {
+	EncodeSQL(w io.Writer, e SQLEntry) error
+	EncodePerf(w io.Writer, e PerfEntry) error
+	EncodeRoute(w io.Writer, e RouteEntry) error
+}
+
+// TSVEncoder is default Encoder writing tab separated values with "# " header line
+// CRC32 of each line is added as last column if Config.ChecksumLines is set.
+type TSVEncoder struct{}
+
+// EncodeHeader write BOM (Config.OutputEncoding "utf8bom") and "# " header line (with crc32 column if Config.ChecksumLines)
+func (TSVEncoder) EncodeHeader(w io.Writer, columns []string) error {
+	fprintBOM(w)
+	if config.ChecksumLines {
+		columns = append(columns[:len(columns):len(columns)], checksumColumn)
+	}
+	_, err := io.WriteString(w, "# "+strings.Join(columns, "\t")+"\n")
+	return err
+}
+
+func (TSVEncoder) EncodeSQL(w io.Writer, e SQLEntry) error {
+	return writeTSV(w, sqlValues(e))
+}
+
+func (TSVEncoder) EncodePerf(w io.Writer, e PerfEntry) error {
+	return writeTSV(w, perfValues(e))
+}
+
+func (TSVEncoder) EncodeRoute(w io.Writer, e RouteEntry) error {
+	return writeTSV(w, routeValues(e))
+}
+
+func writeTSV(w io.Writer, values []string) error {
+	line := strings.Join(values, "\t")
+	if config.ChecksumLines {
+		line = appendChecksum(line)
+	}
+	_, err := io.WriteString(w, line+"\n")
+	return err
+}
+
+// CSVEncoder is Encoder writing RFC 4180 CSV with header row (fields containing delimiter, quote or newline are quoted)
+type CSVEncoder struct{}
+
+// EncodeHeader write BOM (Config.OutputEncoding "utf8bom") and header row
+func (CSVEncoder) EncodeHeader(w io.Writer, columns []string) error {
+	fprintBOM(w)
+	return writeCSV(w, columns)
+}
+
+func (CSVEncoder) EncodeSQL(w io.Writer, e SQLEntry) error {
+	return writeCSV(w, sqlValues(e))
+}
+
+func (CSVEncoder) EncodePerf(w io.Writer, e PerfEntry) error {
+	return writeCSV(w, perfValues(e))
+}
+
+func (CSVEncoder) EncodeRoute(w io.Writer, e RouteEntry) error {
+	return writeCSV(w, routeValues(e))
+}
+
+func writeCSV(w io.Writer, values []string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = config.csvDelimiter()
+	if err := cw.Write(values); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// JSONEncoder is Encoder writing a JSON object per line (keys are column names)
+type JSONEncoder struct{}
+
+func (JSONEncoder) EncodeSQL(w io.Writer, e SQLEntry) error {
+	return writeJSON(w, struct {
+		StartNs    int64  `json:"start_ns"`
+		DurationNs int64  `json:"duration_ns"`
+		Tag        string `json:"tag"`
+		Query      string `json:"query"`
+		PerfTag    string `json:"perf_tag"`
+		Cacheable  bool   `json:"cacheable"`
+		CPUID      string `json:"cpu_id"`
+	}{e.StartTime, e.Duration.Nanoseconds(), e.Tag, e.Query, e.PerfTag, e.Cacheable, e.CPUID})
+}
+
+func (JSONEncoder) EncodePerf(w io.Writer, e PerfEntry) error {
+	return writeJSON(w, struct {
+		StartNs    int64  `json:"start_ns"`
+		DurationNs int64  `json:"duration_ns"`
+		Tag        string `json:"tag"`
+		Text       string `json:"text"`
+		Caller     string `json:"caller"`
+		CPU        string `json:"cpu"`
+	}{e.StartTime, e.Duration.Nanoseconds(), e.Tag, e.Text, e.Caller, e.CPU})
+}
+
+func (JSONEncoder) EncodeRoute(w io.Writer, e RouteEntry) error {
+	return writeJSON(w, struct {
+		StartNs    int64    `json:"start_ns"`
+		DurationNs int64    `json:"duration_ns"`
+		Tag        string   `json:"tag"`
+		Text       string   `json:"text"`
+		Cacheable  bool     `json:"cacheable"`
+		CacheHit   bool     `json:"cache_hit"`
+		Duplicate  bool     `json:"duplicate"`
+		WriteNs    int64    `json:"write_ns"`
+		Tags       []string `json:"tags"`
+		Params     string   `json:"params"`
+	}{e.StartTime, e.Duration.Nanoseconds(), e.Tag, e.Text, e.Cacheable, e.CacheHit, e.Duplicate, e.WriteDuration.Nanoseconds(), e.Tags, e.Params})
+}
+
+// writeJSON write v as a line (non-ASCII characters are escaped as \uXXXX by Config.OutputEncoding "ascii")
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, encodeText(string(data))+"\n")
+	return err
+}
+
+// Values of entries in order of columns (text is encoded by Config.OutputEncoding)
+
+func sqlValues(e SQLEntry) []string {
+	return []string{
+		strconv.FormatInt(e.StartTime, 10),
+		strconv.FormatInt(e.Duration.Nanoseconds(), 10),
+		encodeText(e.Tag),
+		encodeText(e.Query),
+		encodeText(e.PerfTag),
+		strconv.FormatBool(e.Cacheable),
+		e.CPUID,
+	}
+}
+
+func perfValues(e PerfEntry) []string {
+	return []string{
+		strconv.FormatInt(e.StartTime, 10),
+		strconv.FormatInt(e.Duration.Nanoseconds(), 10),
+		encodeText(e.Tag),
+		encodeText(e.Text),
+		encodeText(e.Caller),
+		e.CPU,
+	}
+}
+
+func routeValues(e RouteEntry) []string {
+	return []string{
+		strconv.FormatInt(e.StartTime, 10),
+		strconv.FormatInt(e.Duration.Nanoseconds(), 10),
+		encodeText(e.Tag),
+		encodeText(e.Text),
+		strconv.FormatBool(e.Cacheable),
+		strconv.FormatBool(e.CacheHit),
+		strconv.FormatBool(e.Duplicate),
+		strconv.FormatInt(e.WriteDuration.Nanoseconds(), 10),
+		encodeText(strings.Join(e.Tags, ",")),
+		encodeText(e.Params),
+	}
+}
+
+func (c *Config) csvDelimiter() rune {
+	if c.CSVDelimiter == 0 {
+		return ','
+	}
+	return c.CSVDelimiter
+}
+
+func (c *Config) encoder() Encoder {
+	if c.Encoder == nil {
+		return TSVEncoder{}
+	}
+	return c.Encoder
+}
+
+// encodeHeader write header of Config.Encoder to new log file
+func encodeHeader(w io.Writer, columns []string) {
+	if h, ok := config.encoder().(interface {
+		EncodeHeader(w io.Writer, columns []string) error
+	}); ok {
+		h.EncodeHeader(w, columns)
+	}
+}
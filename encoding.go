@@ -0,0 +1,54 @@
+package tracer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Config.OutputEncoding values
+const (
+	EncodingUTF8    = "utf8"
+	EncodingUTF8BOM = "utf8bom"
+	EncodingASCII   = "ascii"
+)
+
+const utf8BOM = "\xef\xbb\xbf"
+
+// fprintBOM write BOM to new log file if Config.OutputEncoding is "utf8bom"
+func fprintBOM(w io.Writer) {
+	if config.OutputEncoding == EncodingUTF8BOM {
+		io.WriteString(w, utf8BOM)
+	}
+}
+
+// encodeText escape non-ASCII characters as \uXXXX if Config.OutputEncoding is "ascii"
+// Characters out of BMP are escaped as surrogate pair, and invalid bytes as \ufffd.
+func encodeText(s string) string {
+	if config.OutputEncoding != EncodingASCII {
+		return s
+	}
+	i := 0
+	for i < len(s) && s[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(s) {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(s[:i])
+	for _, r := range s[i:] {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&b, "\\u%04x", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package tracer
+
+import "testing"
+
+func TestEncodeText(t *testing.T) {
+	SetConfig(Config{OutputEncoding: EncodingASCII})
+	defer SetConfig(Config{})
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"name = 'あ'", `name = '\u3042'`},
+		{"😀", `\ud83d\ude00`},
+		{"a\xffb", `a\ufffdb`},
+	}
+	for _, tt := range tests {
+		if got := encodeText(tt.s); got != tt.want {
+			t.Errorf("encodeText(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeTextUTF8(t *testing.T) {
+	SetConfig(Config{})
+	if got := encodeText("あ"); got != "あ" {
+		t.Errorf("encodeText(%q) = %q, want unchanged", "あ", got)
+	}
+}
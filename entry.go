@@ -0,0 +1,134 @@
+package tracer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SQLEntry is SQL Query Measurement
+type SQLEntry struct {
+	StartTime int64 // Unix time in nanoseconds
+	Duration  time.Duration
+	Tag       string
+	Query     string
+	PerfTag   string // Tag of PerfHandle in Context (MeasureContext)
+	Cacheable bool   // Same query and params was executed with no write to its tables since (Config.TrackCacheableQueries)
+	CPUID     string // CPU core running the query when it started (Config.TrackCPUAffinity, Linux only)
+}
+
+// PerfEntry is Perfomance Measurement
+type PerfEntry struct {
+	StartTime int64 // Unix time in nanoseconds
+	Duration  time.Duration
+	Tag       string
+	Text      string
+	Caller    string // "file:line" of Measure caller (Config.RecordCaller)
+	CPU       string // CPU utilization (%) of the host during measurement (Config.AnnotateCPU, Linux only)
+}
+
+// RouteEntry is Web Route Perfomance Measurement
+type RouteEntry struct {
+	StartTime     int64 // Unix time in nanoseconds
+	Duration      time.Duration
+	Tag           string
+	Text          string
+	Cacheable     bool          // Response has Cache-Control or ETag header (Middleware only)
+	CacheHit      bool          // Response has "X-Cache: HIT" header (Middleware only)
+	Duplicate     bool          // X-Request-Nonce header was seen within last 60 seconds (Middleware only)
+	WriteDuration time.Duration // Time blocked in ResponseWriter.Write and Flush (Config.MeasureResponseBuffering, Middleware only)
+	Tags          []string      // Labels added by TagRequest (Middleware only)
+	Params        string        // Path parameters of router (PerfHandle.SetParams)
+}
+
+// Columns of log files (written as "# " header line on Start)
+var sqlLogColumns = []string{"start_ns", "duration_ns", "tag", "query", "perf_tag", "cacheable", "cpu_id"}
+var perfLogColumns = []string{"start_ns", "duration_ns", "tag", "text", "caller", "cpu"}
+var routeLogColumns = []string{"start_ns", "duration_ns", "tag", "text", "cacheable", "cache_hit", "duplicate", "write_ns", "tags", "params"}
+
+// fprintHeader write BOM (Config.OutputEncoding "utf8bom") and header line to new log file
+func fprintHeader(file *os.File, columns []string) {
+	fprintBOM(file)
+	fmt.Fprintf(file, "# %s\n", strings.Join(columns, "\t"))
+}
+
+func fprintSQL(file *os.File, e SQLEntry) {
+	config.encoder().EncodeSQL(logWriter(file), e)
+}
+
+func fprintPerf(file *os.File, e PerfEntry) {
+	config.encoder().EncodePerf(logWriter(file), e)
+}
+
+func fprintRoute(file *os.File, e RouteEntry) {
+	config.encoder().EncodeRoute(logWriter(file), e)
+}
+
+const slowWriteThreshold = time.Millisecond
+const slowWriteWarningInterval = 10 * time.Second
+
+var lastSlowWriteWarning int64
+
+// warnSlowWrite warn to stderr (at most once per 10 seconds) when writing log entry takes too long
+func warnSlowWrite(d time.Duration) {
+	if d <= slowWriteThreshold {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&lastSlowWriteWarning)
+	if now-last < int64(slowWriteWarningInterval) || !atomic.CompareAndSwapInt64(&lastSlowWriteWarning, last, now) {
+		return
+	}
+	log.Printf("ISUCON Tracer Warning: writing SQL log took %s (tracer itself may be a bottleneck)\n", d)
+}
+
+func writeSQL(file *os.File, e SQLEntry) {
+	writeStart := time.Now()
+	fprintSQL(file, e)
+	warnSlowWrite(time.Since(writeStart))
+	atomic.AddInt64(&sqlCount, 1)
+	addSQLSummary(e)
+	addHeatmap("sql", e.StartTime, e.Duration)
+	if config.MemoryOnly {
+		recentSQL.add(e)
+	}
+	for _, s := range config.Sinks {
+		s.WriteSQL(e)
+	}
+	checkAlert(e.Duration, e)
+}
+
+func writePerf(file *os.File, e PerfEntry) {
+	fprintPerf(file, e)
+	atomic.AddInt64(&perfCount, 1)
+	addHeatmap("perf", e.StartTime, e.Duration)
+	if config.MemoryOnly {
+		recentPerf.add(e)
+	}
+	for _, s := range config.Sinks {
+		s.WritePerf(e)
+	}
+	checkAlert(e.Duration, e)
+}
+
+func writeRoute(file *os.File, e RouteEntry) {
+	fprintRoute(file, e)
+	atomic.AddInt64(&routeCount, 1)
+	addRouteSummary(e)
+	addHeatmap("route", e.StartTime, e.Duration)
+	addRouteWindow(e)
+	for _, s := range config.Sinks {
+		s.WriteRoute(e)
+	}
+	checkAlert(e.Duration, e)
+}
+
+// checkAlert call Config.OnAlert in new goroutine if d exceeds Config.AlertThreshold
+func checkAlert(d time.Duration, e interface{}) {
+	if config.OnAlert != nil && config.AlertThreshold > 0 && d > config.AlertThreshold {
+		go config.OnAlert(e)
+	}
+}
@@ -0,0 +1,35 @@
+package tracer
+
+import (
+	"fmt"
+	"log"
+)
+
+var startFDCount int
+
+// resetFDCount record number of open file descriptors after files of Tracer are opened
+func resetFDCount() {
+	startFDCount = countOpenFDs()
+}
+
+// fdLeakCount return growth of open file descriptors since Start (or Checkpoint)
+func fdLeakCount() int {
+	if startFDCount <= 0 {
+		return 0
+	}
+	n := countOpenFDs() - startFDCount
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// fdLeakWarning return warning (also written to log) if open file descriptors grew
+func fdLeakWarning(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	w := fmt.Sprintf("%d file descriptors are left open since Start (close *sql.Rows, *os.File and response bodies)", n)
+	log.Printf("ISUCON Tracer Warning: %s\n", w)
+	return w
+}
@@ -0,0 +1,12 @@
+package tracer
+
+import "os"
+
+// countOpenFDs return number of open file descriptors of this process from /proc/self/fd
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
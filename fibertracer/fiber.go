@@ -0,0 +1,47 @@
+// Package fibertracer is Fiber Middleware for ISUCON Tracer
+//
+//	app := fiber.New()
+//	app.Use(fibertracer.Middleware())
+package fibertracer
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+// Middleware make create New Fiber Middleware measuring each request into webroute.log
+// Tag is the route path (c.Route().Path, e.g. "/users/:id") of the matched handler, otherwise the URL path.
+// Path parameters are written to params column, and 5xx status is counted for Config.EnableAlarms.
+// Route concurrency is counted by the URL path until the route is resolved, then moved to the route path.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// c.Path() and c.Method() refer fasthttp buffer reused after the request, so copy them
+		p := tracer.WebRouteMeasure(string([]byte(c.Path())), string([]byte(c.Method())))
+		middleware := c.Route()
+		err := c.Next()
+		// c.Route() is the route of the last matched handler after c.Next()
+		if route := c.Route(); route != middleware {
+			p.SetTag(route.Path)
+			if len(route.Params) > 0 {
+				values := url.Values{}
+				for _, name := range route.Params {
+					values.Add(name, c.Params(name))
+				}
+				p.SetParams(values.Encode())
+			}
+		}
+		status := c.Response().StatusCode()
+		if err != nil {
+			// status is set by app ErrorHandler after Middleware returns
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+		p.SetStatus(status)
+		p.End()
+		return err
+	}
+}
@@ -0,0 +1,39 @@
+package tracer
+
+import (
+	"regexp"
+	"sync"
+)
+
+var regexStringLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"`)
+var regexNumberLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+var regexInList = regexp.MustCompile(`(?i)\bIN \(\?(?:, ?\?)*\)`)
+var regexValuesList = regexp.MustCompile(`(?i)\bVALUES \(.*?\)(?:, ?\(.*?\))+$`)
+
+const maxFingerprintCache = 10000
+
+var fingerprintCacheMutex sync.Mutex
+var fingerprintCache = map[string]string{}
+
+// Fingerprint return normalized SQL query (literals replaced with "?", IN lists collapsed)
+// Query is expected to be whitespace-normalized like sql.log
+func Fingerprint(query string) string {
+	fingerprintCacheMutex.Lock()
+	fp, ok := fingerprintCache[query]
+	fingerprintCacheMutex.Unlock()
+	if ok {
+		return fp
+	}
+
+	fp = regexStringLiteral.ReplaceAllString(query, "?")
+	fp = regexNumberLiteral.ReplaceAllString(fp, "?")
+	fp = regexInList.ReplaceAllString(fp, "IN (...)")
+	fp = regexValuesList.ReplaceAllString(fp, "VALUES (...)")
+
+	fingerprintCacheMutex.Lock()
+	if len(fingerprintCache) < maxFingerprintCache {
+		fingerprintCache[query] = fp
+	}
+	fingerprintCacheMutex.Unlock()
+	return fp
+}
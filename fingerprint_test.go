@@ -0,0 +1,23 @@
+package tracer
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM users WHERE name = 'it''s' AND email = \"a@b\"", "SELECT * FROM users WHERE name = ? AND email = ?"},
+		{"SELECT * FROM users WHERE price > 1.5", "SELECT * FROM users WHERE price > ?"},
+		{"SELECT * FROM users WHERE id IN (1, 2, 3)", "SELECT * FROM users WHERE id IN (...)"},
+		{"SELECT * FROM users WHERE id in (1,2)", "SELECT * FROM users WHERE id IN (...)"},
+		{"INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b')", "INSERT INTO users (id, name) VALUES (...)"},
+		{"SELECT * FROM users2", "SELECT * FROM users2"},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.query); got != tt.want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"time"
+)
+
+// FixtureConfig is parameters of GenerateTestFixture (zero values are defaults)
+type FixtureConfig struct {
+	// Fingerprints is number of unique SQL query fingerprints (default: 50)
+	Fingerprints int
+	// Routes is number of unique web routes (default: 10)
+	Routes int
+	// ZipfS is exponent (> 1) of Zipfian frequency distribution of fingerprints and routes (default: 1.2)
+	ZipfS float64
+	// LatencyMedian is median of log-normal latency distribution (default: 1ms)
+	LatencyMedian time.Duration
+	// LatencySigma is sigma of log-normal latency distribution (default: 1.0)
+	LatencySigma float64
+	// Duration is time span of generated logs (default: 60s)
+	Duration time.Duration
+	// RequestsPerSecond is number of web route entries per second (default: 100)
+	RequestsPerSecond int
+	// QueriesPerRequest is number of SQL entries per web route entry (default: 5)
+	QueriesPerRequest int
+	// StartTime is start time of generated logs (default: now)
+	StartTime time.Time
+	// Seed is seed of random numbers, same seed generates same logs (default: 1)
+	Seed int64
+}
+
+func (c *FixtureConfig) setDefaults() {
+	if c.Fingerprints <= 0 {
+		c.Fingerprints = 50
+	}
+	if c.Routes <= 0 {
+		c.Routes = 10
+	}
+	if c.ZipfS <= 1 {
+		c.ZipfS = 1.2
+	}
+	if c.LatencyMedian <= 0 {
+		c.LatencyMedian = time.Millisecond
+	}
+	if c.LatencySigma <= 0 {
+		c.LatencySigma = 1.0
+	}
+	if c.Duration <= 0 {
+		c.Duration = 60 * time.Second
+	}
+	if c.RequestsPerSecond <= 0 {
+		c.RequestsPerSecond = 100
+	}
+	if c.QueriesPerRequest <= 0 {
+		c.QueriesPerRequest = 5
+	}
+	if c.StartTime.IsZero() {
+		c.StartTime = now()
+	}
+	if c.Seed == 0 {
+		c.Seed = 1
+	}
+}
+
+// GenerateTestFixture write sql.log, perf.log and webroute.log of a synthetic trace to dir for testing analysis tools
+// Each request is a web route entry containing QueriesPerRequest SQL entries and one perf entry in sequence.
+// Fingerprints and routes are chosen by Zipfian distribution, and latencies by log-normal distribution.
+// Files are written by Config.Encoder (default: TSVEncoder).
+func GenerateTestFixture(dir string, cfg FixtureConfig) error {
+	cfg.setDefaults()
+	r := rand.New(rand.NewSource(cfg.Seed))
+	queryZipf := rand.NewZipf(r, cfg.ZipfS, 1, uint64(cfg.Fingerprints-1))
+	routeZipf := rand.NewZipf(r, cfg.ZipfS, 1, uint64(cfg.Routes-1))
+	latency := func() time.Duration {
+		return time.Duration(float64(cfg.LatencyMedian) * math.Exp(r.NormFloat64()*cfg.LatencySigma))
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	var writers []*bufio.Writer
+	for _, log := range []struct {
+		name    string
+		columns []string
+	}{
+		{"sql", sqlLogColumns},
+		{"perf", perfLogColumns},
+		{"webroute", routeLogColumns},
+	} {
+		file, err := os.Create(path.Join(dir, log.name+".log"))
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		w := bufio.NewWriter(file)
+		encodeHeader(w, log.columns)
+		writers = append(writers, w)
+	}
+
+	enc := config.encoder()
+	requests := int(cfg.Duration.Seconds() * float64(cfg.RequestsPerSecond))
+	if requests < 1 {
+		requests = 1
+	}
+	interval := cfg.Duration / time.Duration(requests)
+	for i := 0; i < requests; i++ {
+		requestStart := cfg.StartTime.Add(interval * time.Duration(i)).UnixNano()
+		route := routeZipf.Uint64()
+		t := requestStart
+		for j := 0; j < cfg.QueriesPerRequest; j++ {
+			q := queryZipf.Uint64()
+			d := latency()
+			query := fmt.Sprintf("SELECT * FROM fixture_table%d WHERE column%d = ?", q%10, q)
+			if err := enc.EncodeSQL(writers[0], SQLEntry{StartTime: t, Duration: d, Query: query, PerfTag: fmt.Sprintf("handler%d", route)}); err != nil {
+				return err
+			}
+			t += int64(d)
+		}
+		d := latency()
+		if err := enc.EncodePerf(writers[1], PerfEntry{StartTime: t, Duration: d, Tag: fmt.Sprintf("render%d", route), Text: "fixture"}); err != nil {
+			return err
+		}
+		t += int64(d)
+		if err := enc.EncodeRoute(writers[2], RouteEntry{StartTime: requestStart, Duration: time.Duration(t - requestStart), Tag: fmt.Sprintf("/fixture/route%d", route), Text: "GET"}); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	files = nil
+	return nil
+}
@@ -0,0 +1,144 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"os"
+	"sort"
+
+	"github.com/google/pprof/profile"
+)
+
+const flamegraphWidth = 1200
+const flamegraphFrameHeight = 16
+const flamegraphCharWidth = 7
+
+// flameNode is a frame of flamegraph with total sample value of its stacks
+type flameNode struct {
+	name     string
+	value    int64
+	children map[string]*flameNode
+}
+
+func (n *flameNode) child(name string) *flameNode {
+	c, ok := n.children[name]
+	if !ok {
+		c = &flameNode{name: name, children: map[string]*flameNode{}}
+		n.children[name] = c
+	}
+	return c
+}
+
+func (n *flameNode) depth() int {
+	d := 0
+	for _, c := range n.children {
+		if cd := c.depth(); cd > d {
+			d = cd
+		}
+	}
+	return d + 1
+}
+
+// sortedChildren return children in name order (same order as flamegraph.pl)
+func (n *flameNode) sortedChildren() []*flameNode {
+	children := make([]*flameNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+func buildFlameTree(p *profile.Profile) *flameNode {
+	root := &flameNode{name: "all", children: map[string]*flameNode{}}
+	valueIndex := len(p.SampleType) - 1
+	for _, s := range p.Sample {
+		v := s.Value[valueIndex]
+		root.value += v
+		n := root
+		// Location[0] is the leaf, and Line[0] of a location is the innermost inlined function
+		for i := len(s.Location) - 1; i >= 0; i-- {
+			lines := s.Location[i].Line
+			for j := len(lines) - 1; j >= 0; j-- {
+				name := "?"
+				if lines[j].Function != nil {
+					name = lines[j].Function.Name
+				}
+				n = n.child(name)
+				n.value += v
+			}
+		}
+	}
+	return root
+}
+
+func flameColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	x := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+x%50, 80+(x>>8)%130, 40+(x>>16)%50)
+}
+
+func writeFlameNode(w *bufio.Writer, n *flameNode, total int64, x float64, depth int, maxDepth int) {
+	width := float64(n.value) / float64(total) * flamegraphWidth
+	if width < 0.5 {
+		return
+	}
+	y := (maxDepth - depth - 1) * flamegraphFrameHeight
+	label := ""
+	if chars := int(width-4) / flamegraphCharWidth; chars >= 3 {
+		label = n.name
+		if len(label) > chars {
+			label = label[:chars-2] + ".."
+		}
+	}
+	fmt.Fprintf(w, "<g><title>%s (%.2f%%)</title>", html.EscapeString(n.name), float64(n.value)/float64(total)*100)
+	fmt.Fprintf(w, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2"/>`, x, y, width, flamegraphFrameHeight-1, flameColor(n.name))
+	if label != "" {
+		fmt.Fprintf(w, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flamegraphFrameHeight-4, html.EscapeString(label))
+	}
+	fmt.Fprintln(w, "</g>")
+	for _, c := range n.sortedChildren() {
+		writeFlameNode(w, c, total, x, depth+1, maxDepth)
+		x += float64(c.value) / float64(total) * flamegraphWidth
+	}
+}
+
+// GenerateFlamegraph write flamegraph SVG of pprof profile (e.g. cpu.pprof written on Stop)
+// Frame width is the last sample type of the profile (CPU time for CPU profile).
+//
+//	tracer.Stop()
+//	tracer.GenerateFlamegraph("/tmp/cpu.pprof", "/tmp/flamegraph.svg")
+func GenerateFlamegraph(profilePath string, outputPath string) error {
+	in, err := os.Open(profilePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	p, err := profile.Parse(in)
+	if err != nil {
+		return err
+	}
+
+	root := buildFlameTree(p)
+	maxDepth := root.depth()
+	height := maxDepth * flamegraphFrameHeight
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="Verdana" font-size="12">`+"\n", flamegraphWidth, height)
+	if root.value > 0 {
+		writeFlameNode(w, root, root.value, 0, 0, maxDepth)
+	}
+	fmt.Fprintln(w, "</svg>")
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
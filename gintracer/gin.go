@@ -0,0 +1,54 @@
+// Package gintracer is Gin Middleware for ISUCON Tracer
+//
+//	r := gin.New()
+//	r.Use(gintracer.GinRequestIDMiddleware(), gintracer.GinMiddleware())
+package gintracer
+
+import (
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+)
+
+// RequestIDHeader is request header of Request ID
+const RequestIDHeader = "X-Request-ID"
+
+// GinMiddleware make create New Gin Middleware measuring each request into webroute.log
+// Tag is the route path (c.FullPath(), e.g. "/users/:id") if matched, otherwise the URL path.
+// Path parameters are written to params column (e.g. "id=1"), and 5xx status is counted for Config.EnableAlarms.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := c.FullPath()
+		if tag == "" {
+			tag = c.Request.URL.Path
+		}
+		p := tracer.WebRouteMeasure(tag, c.Request.Method)
+		if len(c.Params) > 0 {
+			values := url.Values{}
+			for _, param := range c.Params {
+				values.Add(param.Key, param.Value)
+			}
+			p.SetParams(values.Encode())
+		}
+		c.Next()
+		p.SetStatus(c.Writer.Status())
+		p.End()
+	}
+}
+
+// GinRequestIDMiddleware make create New Gin Middleware storing Request ID in request Context
+// Request ID is X-Request-ID header, or UUID generated if not present (set to response header).
+// SQL queries executed with the Context are counted per Request ID (Config.MaxQueriesPerRequest).
+func GinRequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = tracer.NewRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(tracer.WithRequestID(c.Request.Context(), id))
+		defer tracer.ReleaseRequestID(id)
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package tracer
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// goroutinePaths is perf path of active MeasureContext per goroutine ID (Config.GoroutineLocalContext)
+//
+// Go has no goroutine-local storage, so goroutine ID is parsed from the first line of runtime.Stack
+// ("goroutine 123 [running]:"). Limitations:
+//   - runtime.Stack costs about 1us per call, only used when Config.GoroutineLocalContext is set
+//   - goroutine ID is not a public API, the format of runtime.Stack may change in future Go versions
+//   - goroutines started inside a measurement do not inherit it (pass Context to them)
+//   - End must be called on the same goroutine as MeasureContext, in reverse order (use defer)
+var goroutinePaths sync.Map
+
+// goroutineID return ID of current goroutine (0 if not parsable)
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// goroutinePath return perf path of active MeasureContext on current goroutine
+func goroutinePath() []string {
+	if !config.GoroutineLocalContext {
+		return nil
+	}
+	if path, ok := goroutinePaths.Load(goroutineID()); ok {
+		return path.([]string)
+	}
+	return nil
+}
+
+// pushGoroutinePath set perf path of current goroutine, and return goroutine ID and previous path to restore on End
+func pushGoroutinePath(path []string) (int64, []string) {
+	gid := goroutineID()
+	if gid == 0 {
+		return 0, nil
+	}
+	prev, _ := goroutinePaths.Swap(gid, path)
+	prevPath, _ := prev.([]string)
+	return gid, prevPath
+}
+
+func popGoroutinePath(gid int64, prevPath []string) {
+	if prevPath == nil {
+		goroutinePaths.Delete(gid)
+	} else {
+		goroutinePaths.Store(gid, prevPath)
+	}
+}
@@ -0,0 +1,80 @@
+package tracer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+const defaultSamplingInterval = 100 * time.Millisecond
+
+// goroutineSampler keep goroutine stack traces at the peak of goroutine count (Config.GoroutineSampling)
+type goroutineSampler struct {
+	peakCount int
+	peak      []byte
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+var sampler *goroutineSampler
+
+func (c *Config) samplingInterval() time.Duration {
+	if c.SamplingInterval <= 0 {
+		return defaultSamplingInterval
+	}
+	return c.SamplingInterval
+}
+
+func startGoroutineSampler() {
+	if !config.GoroutineSampling {
+		return
+	}
+	s := &goroutineSampler{done: make(chan struct{})}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(config.samplingInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.sample()
+			}
+		}
+	}()
+	sampler = s
+}
+
+// sample capture stack traces only when goroutine count exceeds the peak (runtime.NumGoroutine is cheap)
+func (s *goroutineSampler) sample() {
+	count := runtime.NumGoroutine()
+	if count <= s.peakCount {
+		return
+	}
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return
+	}
+	s.peakCount = count
+	s.peak = buf.Bytes()
+}
+
+// stopGoroutineSampler write peak goroutine stack traces to fileName
+func stopGoroutineSampler(fileName string) error {
+	if sampler == nil {
+		return nil
+	}
+	close(sampler.done)
+	sampler.wg.Wait()
+	s := sampler
+	sampler = nil
+	if s.peak == nil {
+		return nil
+	}
+	return ioutil.WriteFile(fileName, s.peak, 0644)
+}
@@ -0,0 +1,210 @@
+package tracer
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultGraphiteInterval = 10 * time.Second
+const graphiteDialTimeout = 5 * time.Second
+const graphiteMinBackoff = time.Second
+const graphiteMaxBackoff = time.Minute
+
+var regexGraphiteInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type graphiteMetric struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+type graphiteSink struct {
+	addr     string
+	prefix   string
+	interval time.Duration
+
+	mutex   sync.Mutex
+	metrics map[string]*graphiteMetric
+
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// connection is used only by flush (sendMutex)
+	sendMutex sync.Mutex
+	conn      net.Conn
+	backoff   time.Duration
+	nextDial  time.Time
+	closed    bool
+}
+
+// NewGraphiteSink make create New Sink sending measurements to Graphite Carbon daemon (plaintext protocol over TCP)
+// count, sum_ms and max_ms are aggregated per interval (default: 10s) and sent as metrics like
+// "isucon.{prefix}.sql.{fingerprint hash}.count". Perf and route metrics are named by tag.
+// Connection is re-established with exponential backoff (1s to 1m) after failure, and metrics are dropped while disconnected.
+// Returned Sink implements io.Closer, Close stops sending and closes the connection.
+//
+//	sink := tracer.NewGraphiteSink("127.0.0.1:2003", "isu1", 0)
+//	defer sink.(io.Closer).Close()
+func NewGraphiteSink(addr string, prefix string, interval time.Duration) Sink {
+	if interval <= 0 {
+		interval = defaultGraphiteInterval
+	}
+	s := &graphiteSink{
+		addr:     addr,
+		prefix:   "isucon.",
+		interval: interval,
+		metrics:  map[string]*graphiteMetric{},
+		done:     make(chan struct{}),
+	}
+	if prefix != "" {
+		s.prefix += prefix + "."
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				if err := s.Flush(); err != nil {
+					log.Printf("ISUCON Tracer Error: %s\n", err.Error())
+				}
+			}
+		}
+	}()
+	return s
+}
+
+// graphiteName make metric name segment of tag (characters other than [A-Za-z0-9_-] are replaced by "_")
+func graphiteName(tag string) string {
+	if tag == "" {
+		return "_"
+	}
+	return regexGraphiteInvalidChars.ReplaceAllString(tag, "_")
+}
+
+func graphiteFingerprintHash(query string) string {
+	h := fnv.New32a()
+	h.Write([]byte(Fingerprint(query)))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func (s *graphiteSink) WriteSQL(e SQLEntry) {
+	s.add("sql."+graphiteFingerprintHash(e.Query), e.Duration)
+}
+
+func (s *graphiteSink) WritePerf(e PerfEntry) {
+	s.add("perf."+graphiteName(e.Tag), e.Duration)
+}
+
+func (s *graphiteSink) WriteRoute(e RouteEntry) {
+	s.add("route."+graphiteName(e.Tag), e.Duration)
+}
+
+func (s *graphiteSink) add(name string, d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	m, ok := s.metrics[name]
+	if !ok {
+		m = &graphiteMetric{}
+		s.metrics[name] = m
+	}
+	m.count++
+	m.total += d
+	if d > m.max {
+		m.max = d
+	}
+}
+
+// Flush send metrics aggregated since last flush
+func (s *graphiteSink) Flush() error {
+	s.mutex.Lock()
+	metrics := s.metrics
+	s.metrics = map[string]*graphiteMetric{}
+	s.mutex.Unlock()
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	timestamp := now().Unix()
+	var b bytes.Buffer
+	for _, name := range names {
+		m := metrics[name]
+		fmt.Fprintf(&b, "%s%s.count %d %d\n", s.prefix, name, m.count, timestamp)
+		fmt.Fprintf(&b, "%s%s.sum_ms %.3f %d\n", s.prefix, name, float64(m.total)/float64(time.Millisecond), timestamp)
+		fmt.Fprintf(&b, "%s%s.max_ms %.3f %d\n", s.prefix, name, float64(m.max)/float64(time.Millisecond), timestamp)
+	}
+	return s.send(b.Bytes())
+}
+
+// Close stop periodic flush, send remaining metrics and close the connection
+// Metrics written after Close are not sent.
+func (s *graphiteSink) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.Flush()
+		s.sendMutex.Lock()
+		defer s.sendMutex.Unlock()
+		s.closed = true
+		if s.conn != nil {
+			if cerr := s.conn.Close(); err == nil {
+				err = cerr
+			}
+			s.conn = nil
+		}
+	})
+	return err
+}
+
+// send write batch to connection, connecting if needed (metrics are dropped during backoff)
+func (s *graphiteSink) send(data []byte) error {
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
+	if s.closed {
+		return nil
+	}
+	if s.conn == nil {
+		if time.Now().Before(s.nextDial) {
+			return nil
+		}
+		conn, err := net.DialTimeout("tcp", s.addr, graphiteDialTimeout)
+		if err != nil {
+			s.fail()
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.interval))
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.fail()
+		return err
+	}
+	s.backoff = 0
+	return nil
+}
+
+// fail double backoff before next connection
+func (s *graphiteSink) fail() {
+	if s.backoff == 0 {
+		s.backoff = graphiteMinBackoff
+	} else if s.backoff *= 2; s.backoff > graphiteMaxBackoff {
+		s.backoff = graphiteMaxBackoff
+	}
+	s.nextDial = time.Now().Add(s.backoff)
+}
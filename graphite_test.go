@@ -0,0 +1,54 @@
+package tracer
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGraphiteSinkClose(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		data, _ := ioutil.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	sink := NewGraphiteSink(ln.Addr().String(), "isu1", time.Hour)
+	sink.WritePerf(PerfEntry{Tag: "getUser", Duration: 2 * time.Millisecond})
+	if err := sink.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.(io.Closer).Close(); err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+	sink.WritePerf(PerfEntry{Tag: "afterClose", Duration: time.Millisecond})
+	if err := sink.(interface{ Flush() error }).Flush(); err != nil {
+		t.Errorf("Flush() after Close = %v, want nil", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "isucon.isu1.perf.getUser.count 1 ") {
+			t.Errorf("received %q, want getUser count", data)
+		}
+		if strings.Contains(data, "afterClose") {
+			t.Errorf("received %q, want no metrics after Close", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not closed by Close")
+	}
+}
@@ -0,0 +1,54 @@
+// Package grpctracer is gRPC Request ID propagation for ISUCON Tracer
+//
+//	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpctracer.RequestIDInterceptor()))
+package grpctracer
+
+import (
+	"context"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMetadataKey is incoming metadata key of Request ID
+const RequestIDMetadataKey = "x-request-id"
+
+// RequestIDInterceptor make create New gRPC unary server interceptor storing Request ID in Context
+// Request ID is "x-request-id" of incoming metadata, or UUID generated if not present.
+// SQL queries executed with the Context are counted per Request ID (Config.MaxQueriesPerRequest).
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := requestID(ctx)
+		defer tracer.ReleaseRequestID(id)
+		return handler(tracer.WithRequestID(ctx, id), req)
+	}
+}
+
+// StreamRequestIDInterceptor is RequestIDInterceptor for streaming RPCs
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestID(ss.Context())
+		defer tracer.ReleaseRequestID(id)
+		ctx := tracer.WithRequestID(ss.Context(), id)
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadataKey); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return tracer.NewRequestID()
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,138 @@
+package tracer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// heatmapPercentiles are columns of heatmap rows
+var heatmapPercentiles = []float64{0.50, 0.75, 0.90, 0.99}
+
+// Durations per second are counted by log-scale buckets of 1us to about 4 minutes (4 buckets per octave, relative error about 9%)
+const heatmapBucketsPerOctave = 4
+const heatmapBucketCount = 112
+
+// heatmapHistogram is durations of measurements started in a second (about 460 bytes)
+type heatmapHistogram struct {
+	count   uint32
+	max     time.Duration
+	buckets [heatmapBucketCount]uint32
+}
+
+func heatmapIndex(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	i := int(math.Ceil(math.Log2(us) * heatmapBucketsPerOctave))
+	if i >= heatmapBucketCount {
+		return heatmapBucketCount - 1
+	}
+	return i
+}
+
+// heatmapValue return geometric middle of bucket
+func heatmapValue(i int) time.Duration {
+	if i == 0 {
+		return time.Microsecond
+	}
+	return time.Duration(math.Exp2((float64(i)-0.5)/heatmapBucketsPerOctave) * float64(time.Microsecond))
+}
+
+func (h *heatmapHistogram) add(d time.Duration) {
+	h.buckets[heatmapIndex(d)]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *heatmapHistogram) quantile(q float64) time.Duration {
+	rank := uint32(math.Ceil(q * float64(h.count)))
+	var n uint32
+	for i, c := range h.buckets {
+		n += c
+		if n >= rank {
+			if v := heatmapValue(i); v < h.max {
+				return v
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+var heatmapMutex sync.Mutex
+var heatmapSeconds = map[string]map[int64]*heatmapHistogram{} // kind (sql, perf, route) -> second -> histogram of durations
+
+func resetHeatmap() {
+	heatmapMutex.Lock()
+	defer heatmapMutex.Unlock()
+	heatmapSeconds = map[string]map[int64]*heatmapHistogram{}
+}
+
+// addHeatmap bin measurement by second of start time (Config.HeatmapExport)
+// Memory grows by a fixed-size histogram per second and kind (about 1.4KB per second for sql, perf and route).
+func addHeatmap(kind string, startTime int64, d time.Duration) {
+	if !config.HeatmapExport {
+		return
+	}
+	second := startTime / int64(time.Second)
+	heatmapMutex.Lock()
+	defer heatmapMutex.Unlock()
+	seconds, ok := heatmapSeconds[kind]
+	if !ok {
+		seconds = map[int64]*heatmapHistogram{}
+		heatmapSeconds[kind] = seconds
+	}
+	h, ok := seconds[second]
+	if !ok {
+		h = &heatmapHistogram{}
+		seconds[second] = h
+	}
+	h.add(d)
+}
+
+// heatmapRow is latency percentiles of measurements started in a second (estimated by histogram, relative error about 9%)
+type heatmapRow struct {
+	Time  int64 `json:"time"` // unix seconds
+	Count int   `json:"count"`
+	P50Ns int64 `json:"p50_ns"`
+	P75Ns int64 `json:"p75_ns"`
+	P90Ns int64 `json:"p90_ns"`
+	P99Ns int64 `json:"p99_ns"`
+}
+
+func newHeatmapRow(second int64, h *heatmapHistogram) heatmapRow {
+	var p [4]int64
+	for i, q := range heatmapPercentiles {
+		p[i] = int64(h.quantile(q))
+	}
+	return heatmapRow{Time: second, Count: int(h.count), P50Ns: p[0], P75Ns: p[1], P90Ns: p[2], P99Ns: p[3]}
+}
+
+// writeHeatmap write heatmap-{TraceID}.json having rows per second for sql, perf and route
+// Seconds without measurements have no row.
+func writeHeatmap(fileName string) error {
+	heatmap := map[string][]heatmapRow{}
+	heatmapMutex.Lock()
+	for kind, seconds := range heatmapSeconds {
+		rows := make([]heatmapRow, 0, len(seconds))
+		for second, h := range seconds {
+			rows = append(rows, newHeatmapRow(second, h))
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Time < rows[j].Time })
+		heatmap[kind] = rows
+	}
+	heatmapMutex.Unlock()
+
+	data, err := json.MarshalIndent(heatmap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
@@ -0,0 +1,45 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+)
+
+// heatmapRelativeError is max relative error of bucket middle (2^(1/8) - 1)
+const heatmapRelativeError = 0.091
+
+func TestHeatmapRow(t *testing.T) {
+	SetConfig(Config{HeatmapExport: true})
+	defer SetConfig(Config{})
+	resetHeatmap()
+	defer resetHeatmap()
+
+	second := time.Unix(1700000000, 0)
+	for i := 1; i <= 100; i++ {
+		addHeatmap("route", second.Add(time.Duration(i)*time.Millisecond).UnixNano(), time.Duration(i)*time.Millisecond)
+	}
+	addHeatmap("route", second.Add(time.Second).UnixNano(), time.Millisecond)
+
+	seconds := heatmapSeconds["route"]
+	if len(seconds) != 2 {
+		t.Fatalf("len(seconds) = %d, want 2", len(seconds))
+	}
+	row := newHeatmapRow(second.Unix(), seconds[second.Unix()])
+	if row.Count != 100 {
+		t.Errorf("Count = %d, want 100", row.Count)
+	}
+	for _, tt := range []struct {
+		name string
+		got  int64
+		want time.Duration
+	}{
+		{"P50Ns", row.P50Ns, 50 * time.Millisecond},
+		{"P75Ns", row.P75Ns, 75 * time.Millisecond},
+		{"P90Ns", row.P90Ns, 90 * time.Millisecond},
+		{"P99Ns", row.P99Ns, 99 * time.Millisecond},
+	} {
+		if diff := float64(tt.got) - float64(tt.want); diff > float64(tt.want)*heatmapRelativeError || -diff > float64(tt.want)*heatmapRelativeError {
+			t.Errorf("%s = %v, want %v (relative error %v)", tt.name, time.Duration(tt.got), tt.want, heatmapRelativeError)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package tracer
+
+import (
+	"net/url"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+// HostStat is SQL latency statistics per database host (Config.TrackHostLatency)
+type HostStat struct {
+	Count     int64
+	Mean      time.Duration
+	ErrorRate float64
+}
+
+type hostCounter struct {
+	count  int64
+	sum    int64
+	errors int64
+}
+
+func (h *hostCounter) stat() HostStat {
+	count := atomic.LoadInt64(&h.count)
+	if count == 0 {
+		return HostStat{}
+	}
+	return HostStat{
+		Count:     count,
+		Mean:      time.Duration(atomic.LoadInt64(&h.sum) / count),
+		ErrorRate: float64(atomic.LoadInt64(&h.errors)) / float64(count),
+	}
+}
+
+// connHosts map *proxy.Conn to host of DSN opened the connection
+var connHosts sync.Map
+var hostCounters sync.Map
+
+var regexDSNAddress = regexp.MustCompile(`@\w+\(([^)]*)\)`)
+var regexDSNHost = regexp.MustCompile(`(?:^|\s)host=(\S+)`)
+
+// dsnHost return host of DSN ("" if unknown)
+// MySQL ("user@tcp(host:3306)/db"), URL ("postgres://user@host:5432/db") and
+// key=value ("host=... port=...") formats are supported.
+func dsnHost(dsn string) string {
+	if m := regexDSNAddress.FindStringSubmatch(dsn); m != nil {
+		return m[1]
+	}
+	if u, err := url.Parse(dsn); err == nil && u.Host != "" {
+		return u.Host
+	}
+	if m := regexDSNHost.FindStringSubmatch(dsn); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func addHostStat(conn *proxy.Conn, d time.Duration, err error) {
+	v, ok := connHosts.Load(conn)
+	if !ok || v.(string) == "" {
+		return
+	}
+	c, ok := hostCounters.Load(v)
+	if !ok {
+		c, _ = hostCounters.LoadOrStore(v, &hostCounter{})
+	}
+	h := c.(*hostCounter)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+	if err != nil {
+		atomic.AddInt64(&h.errors, 1)
+	}
+}
+
+func resetHostStats() {
+	hostCounters.Range(func(key, value interface{}) bool {
+		hostCounters.Delete(key)
+		return true
+	})
+}
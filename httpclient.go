@@ -0,0 +1,132 @@
+package tracer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+var httpClientLogFile *LogFile
+var httpClientLogFileOnce sync.Once
+var httpClientRequests int64
+var httpClientReused int64
+
+// httpClientReuseWarningRate is connection reuse rate of HTTP client warned in summary.json
+const httpClientReuseWarningRate = 0.9
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// NewTracingTransport make create New HTTP Client Transport writing httpclient.log
+// Columns: start_ns, duration_ns, method, host, path, status, tls_handshake_ns, tls_version, tls_cipher_suite, tls_resumed,
+// dns_ns, resolved_ip, dns_coalesced (Config.TraceDNS), connection_reused
+// Connection reuse rate below 90% is warned in summary.json (keep-alive is not used, e.g. response body is not closed).
+//
+//	client := &http.Client{Transport: tracer.NewTracingTransport(http.DefaultTransport)}
+func NewTracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClientLogFileOnce.Do(func() {
+		httpClientLogFile = NewLogFile("httpclient")
+	})
+	return &tracingTransport{base: base}
+}
+
+// clientTrace is timings of a request reported by httptrace (callbacks may be called on other goroutines)
+type clientTrace struct {
+	mutex             sync.Mutex
+	tlsHandshakeStart int64
+	tlsHandshake      int64
+	dnsStart          int64
+	dns               int64
+	resolvedIP        string
+	dnsCoalesced      bool
+	reused            bool
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct := &clientTrace{}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			ct.mutex.Lock()
+			ct.reused = info.Reused
+			ct.mutex.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			ct.mutex.Lock()
+			ct.tlsHandshakeStart = now().UnixNano()
+			ct.mutex.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			ct.mutex.Lock()
+			ct.tlsHandshake = now().UnixNano() - ct.tlsHandshakeStart
+			ct.mutex.Unlock()
+		},
+	}
+	if config.TraceDNS {
+		// Go resolver has no cache, DNS lookup shared with concurrent dial to same host is reported as coalesced
+		// (no DNS columns if the connection is reused or host is IP address)
+		trace.DNSStart = func(httptrace.DNSStartInfo) {
+			ct.mutex.Lock()
+			ct.dnsStart = now().UnixNano()
+			ct.mutex.Unlock()
+		}
+		trace.DNSDone = func(info httptrace.DNSDoneInfo) {
+			ct.mutex.Lock()
+			ct.dns = now().UnixNano() - ct.dnsStart
+			if len(info.Addrs) > 0 {
+				ct.resolvedIP = info.Addrs[0].String()
+			}
+			ct.dnsCoalesced = info.Coalesced
+			ct.mutex.Unlock()
+		}
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	startTime := now().UnixNano()
+	resp, err := t.base.RoundTrip(req)
+	timeDelta := now().UnixNano() - startTime
+	status := 0
+	tlsVersion, cipherSuite, resumed := "", "", false
+	if resp != nil {
+		status = resp.StatusCode
+		if resp.TLS != nil {
+			tlsVersion = tls.VersionName(resp.TLS.Version)
+			cipherSuite = tls.CipherSuiteName(resp.TLS.CipherSuite)
+			resumed = resp.TLS.DidResume
+		}
+	}
+	ct.mutex.Lock()
+	tlsHandshake, dns, resolvedIP, dnsCoalesced, reused := ct.tlsHandshake, ct.dns, ct.resolvedIP, ct.dnsCoalesced, ct.reused
+	ct.mutex.Unlock()
+	if TraceID != "" {
+		atomic.AddInt64(&httpClientRequests, 1)
+		if reused {
+			atomic.AddInt64(&httpClientReused, 1)
+		}
+	}
+	httpClientLogFile.Printf("%d\t%d\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%t\t%d\t%s\t%t\t%t\n", startTime, timeDelta, req.Method, req.URL.Host, req.URL.Path, status, tlsHandshake, tlsVersion, cipherSuite, resumed, dns, resolvedIP, dnsCoalesced, reused)
+	return resp, err
+}
+
+func resetHTTPClientCounts() {
+	atomic.StoreInt64(&httpClientRequests, 0)
+	atomic.StoreInt64(&httpClientReused, 0)
+}
+
+// httpClientReuseWarning return warning if connection reuse rate of NewTracingTransport is below 90%
+func httpClientReuseWarning() string {
+	requests := atomic.LoadInt64(&httpClientRequests)
+	if requests == 0 {
+		return ""
+	}
+	rate := float64(atomic.LoadInt64(&httpClientReused)) / float64(requests)
+	if rate >= httpClientReuseWarningRate {
+		return ""
+	}
+	return fmt.Sprintf("HTTP client connection reuse rate is %.1f%% (%d requests), keep-alive may not be used (close response body, set MaxIdleConnsPerHost)", rate*100, requests)
+}
@@ -0,0 +1,87 @@
+package tracer
+
+import (
+	"container/heap"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var inFlightID uint64
+var inFlight sync.Map
+var inFlightCount int64
+var overflowCount int64
+
+func startInFlight(startTime int64, tag string, text string) uint64 {
+	id := atomic.AddUint64(&inFlightID, 1)
+	inFlight.Store(id, PerfEntry{StartTime: startTime, Tag: tag, Text: text})
+	atomic.AddInt64(&inFlightCount, 1)
+	return id
+}
+
+func endInFlight(id uint64) {
+	if _, ok := inFlight.LoadAndDelete(id); ok {
+		atomic.AddInt64(&inFlightCount, -1)
+	}
+}
+
+// isInFlightOverflow report whether Config.MaxInFlight measurements are in-flight
+// Overflowed measurements are counted, and warned once per trace.
+func isInFlightOverflow() bool {
+	if config.MaxInFlight <= 0 || atomic.LoadInt64(&inFlightCount) < int64(config.MaxInFlight) {
+		return false
+	}
+	if atomic.AddInt64(&overflowCount, 1) == 1 {
+		log.Printf("ISUCON Tracer Warning: more than %d measurements are in-flight (End is not called?)\n", config.MaxInFlight)
+	}
+	return true
+}
+
+func resetOverflowCount() {
+	atomic.StoreInt64(&overflowCount, 0)
+}
+
+// inFlightHeap is heap of measurements keeping the latest started one on top
+type inFlightHeap []PerfEntry
+
+func (h inFlightHeap) Len() int            { return len(h) }
+func (h inFlightHeap) Less(i, j int) bool  { return h[i].StartTime > h[j].StartTime }
+func (h inFlightHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *inFlightHeap) Push(x interface{}) { *h = append(*h, x.(PerfEntry)) }
+func (h *inFlightHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// InFlightTop return n longest-running measurements which End is not called yet
+// Duration of each entry is the elapsed time until now.
+func InFlightTop(n int) []PerfEntry {
+	if n <= 0 {
+		return nil
+	}
+	h := &inFlightHeap{}
+	inFlight.Range(func(key, value interface{}) bool {
+		e := value.(PerfEntry)
+		if h.Len() < n {
+			heap.Push(h, e)
+		} else if e.StartTime < (*h)[0].StartTime {
+			(*h)[0] = e
+			heap.Fix(h, 0)
+		}
+		return true
+	})
+
+	t := now().UnixNano()
+	entries := []PerfEntry(*h)
+	for i := range entries {
+		entries[i].Duration = time.Duration(t - entries[i].StartTime)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Duration > entries[j].Duration
+	})
+	return entries
+}
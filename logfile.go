@@ -0,0 +1,77 @@
+package tracer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sync"
+)
+
+// LogFile is Additional Log File created on Start and closed on Stop
+type LogFile struct {
+	name string
+	file *os.File
+}
+
+var logFilesMutex sync.Mutex
+var logFiles []*LogFile
+
+// NewLogFile register Additional Log File ({name}.log in log directory)
+// If Tracer is running, the file is created immediately (no file with Config.MemoryOnly).
+func NewLogFile(name string) *LogFile {
+	logFilesMutex.Lock()
+	defer logFilesMutex.Unlock()
+	l := &LogFile{name: name}
+	logFiles = append(logFiles, l)
+	if TraceID != "" && !config.MemoryOnly {
+		file, err := os.Create(logFilePath(config.logDir(), name))
+		if err != nil {
+			log.Printf("ISUCON Tracer Error: %s\n", err.Error())
+		} else {
+			fprintBOM(file)
+		}
+		l.file = file
+	}
+	return l
+}
+
+// Printf write formatted line to Log File while Tracer is running
+func (l *LogFile) Printf(format string, a ...interface{}) {
+	logFilesMutex.Lock()
+	file := l.file
+	logFilesMutex.Unlock()
+	if file != nil {
+		io.WriteString(file, encodeText(fmt.Sprintf(format, a...)))
+	}
+}
+
+func logFilePath(dirName string, name string) string {
+	return path.Join(dirName, name+".log")
+}
+
+func createLogFiles(dirName string) error {
+	logFilesMutex.Lock()
+	defer logFilesMutex.Unlock()
+	for _, l := range logFiles {
+		file, err := os.Create(logFilePath(dirName, l.name))
+		if err != nil {
+			return err
+		}
+		fprintBOM(file)
+		l.file = file
+	}
+	return nil
+}
+
+func closeLogFiles() {
+	logFilesMutex.Lock()
+	defer logFilesMutex.Unlock()
+	for _, l := range logFiles {
+		if l.file != nil {
+			l.file.Close()
+			l.file = nil
+		}
+	}
+}
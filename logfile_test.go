@@ -0,0 +1,28 @@
+package tracer
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestNewLogFileMemoryOnly(t *testing.T) {
+	dir := t.TempDir()
+	SetConfig(Config{LogDir: dir, MemoryOnly: true})
+	defer SetConfig(Config{})
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	p := CacheMeasure("user", "1", true)
+	p.End()
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		t.Errorf("file %s is created with MemoryOnly", f.Name())
+	}
+}
@@ -0,0 +1,38 @@
+package tracer
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readMappedMemory return total size (bytes) of file-backed mappings in /proc/self/smaps
+func readMappedMemory() int64 {
+	file, err := os.Open("/proc/self/smaps")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	var total int64
+	fileBacked := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.HasSuffix(fields[0], ":") {
+			// mapping header: address perms offset dev inode [pathname]
+			fileBacked = len(fields) >= 6 && strings.HasPrefix(fields[5], "/")
+			continue
+		}
+		if fileBacked && fields[0] == "Size:" && len(fields) >= 2 {
+			if kb, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				total += kb * 1024
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package tracer
+
+func readMappedMemory() int64 {
+	return 0
+}
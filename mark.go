@@ -0,0 +1,13 @@
+package tracer
+
+// Mark write zero-duration "MARK" entry with label to sql.log, perf.log and webroute.log
+// Use to annotate the log timeline (e.g. tracer.Mark("bench_start"))
+func Mark(label string) {
+	if TraceID == "" {
+		return
+	}
+	t := now().UnixNano()
+	fprintSQL(sqlLogFile, SQLEntry{StartTime: t, Tag: "MARK", Query: label})
+	fprintPerf(perfomanceLogFile, PerfEntry{StartTime: t, Tag: "MARK", Text: label})
+	fprintRoute(webrouteLogFile, RouteEntry{StartTime: t, Tag: "MARK", Text: label})
+}
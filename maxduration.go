@@ -0,0 +1,39 @@
+package tracer
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+var traceTimerMutex sync.Mutex
+var traceTimer *time.Timer
+
+// startTraceTimer Stop the trace after Config.MaxTraceDuration (timer of previous trace is cancelled)
+func startTraceTimer(traceID string) {
+	traceTimerMutex.Lock()
+	defer traceTimerMutex.Unlock()
+	if traceTimer != nil {
+		traceTimer.Stop()
+		traceTimer = nil
+	}
+	if config.MaxTraceDuration <= 0 {
+		return
+	}
+	traceTimer = time.AfterFunc(config.MaxTraceDuration, func() {
+		if TraceID != traceID {
+			return
+		}
+		log.Printf("ISUCON Tracer Max Trace Duration (%s)\n", config.MaxTraceDuration)
+		logError(stopTrace(traceID))
+	})
+}
+
+func stopTraceTimer() {
+	traceTimerMutex.Lock()
+	defer traceTimerMutex.Unlock()
+	if traceTimer != nil {
+		traceTimer.Stop()
+		traceTimer = nil
+	}
+}
@@ -0,0 +1,94 @@
+package tracer
+
+import (
+	"os"
+	"sync"
+)
+
+// recentSize is number of recent entries kept per log type (Config.MemoryOnly)
+const recentSize = 1000
+
+// entryRing keeps last N entries
+type entryRing struct {
+	mutex   sync.Mutex
+	entries []interface{}
+	next    int
+}
+
+func (r *entryRing) add(e interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.entries) < recentSize {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % recentSize
+}
+
+// list return entries from the oldest
+func (r *entryRing) list() []interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entries := make([]interface{}, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+func (r *entryRing) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries = nil
+	r.next = 0
+}
+
+var recentSQL entryRing
+var recentPerf entryRing
+
+func resetRecent() {
+	recentSQL.reset()
+	recentPerf.reset()
+}
+
+// RecentSQL return last 1000 SQL entries from the oldest (Config.MemoryOnly)
+func RecentSQL() []SQLEntry {
+	list := recentSQL.list()
+	entries := make([]SQLEntry, len(list))
+	for i, e := range list {
+		entries[i] = e.(SQLEntry)
+	}
+	return entries
+}
+
+// RecentPerf return last 1000 perf entries from the oldest (Config.MemoryOnly)
+func RecentPerf() []PerfEntry {
+	list := recentPerf.list()
+	entries := make([]PerfEntry, len(list))
+	for i, e := range list {
+		entries[i] = e.(PerfEntry)
+	}
+	return entries
+}
+
+// openMemoryOnlyLogFiles open os.DevNull as SQL, perf and route log files (Config.MemoryOnly)
+// Measurements are written to ring buffers and route windows (StatusHandler) instead.
+func openMemoryOnlyLogFiles() error {
+	var err error
+	sqlLogFile, perfomanceLogFile, webrouteLogFile = nil, nil, nil
+	if config.logEnabled(SQLLog) {
+		if sqlLogFile, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0); err != nil {
+			return err
+		}
+	}
+	if config.logEnabled(PerfLog) {
+		if perfomanceLogFile, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0); err != nil {
+			return err
+		}
+	}
+	if config.logEnabled(RouteLog) {
+		if webrouteLogFile, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
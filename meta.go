@@ -0,0 +1,31 @@
+package tracer
+
+import "sync"
+
+var metaMutex sync.Mutex
+var metadata = map[string]string{}
+
+// SetMeta set user-defined annotation written to summary.json as "metadata" (e.g. app_version, git_sha)
+// Metadata is kept across Start and Stop
+func SetMeta(key, value string) {
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	metadata[key] = value
+}
+
+// GetMeta return annotation set by SetMeta ("" if not exists)
+func GetMeta(key string) string {
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	return metadata[key]
+}
+
+func copyMeta() map[string]string {
+	metaMutex.Lock()
+	defer metaMutex.Unlock()
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		m[k] = v
+	}
+	return m
+}
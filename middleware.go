@@ -0,0 +1,87 @@
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Middleware make create New HTTP Middleware measuring each request into webroute.log
+// Tag is the ServeMux pattern (r.Pattern) if matched, otherwise the URL path.
+// Requests with X-Request-Nonce header seen within last 60 seconds are flagged as duplicate.
+// While Tracer is running, Server-Timing header has total duration of SQL queries executed
+// with the request Context (db) and elapsed time of the handler until the header is written (handler).
+// With Config.MeasureResponseBuffering, time blocked in Write and Flush (e.g. slow clients) is written to write_ns column.
+// Labels added by TagRequest with the request Context are written to tags column.
+// Request ID (X-Request-ID header, or UUID generated) is stored in the request Context for Config.MaxQueriesPerRequest.
+// WebSocket handshake is measured until the connection is hijacked as "ws_handshake:" + tag.
+//
+//	http.ListenAndServe(":8080", tracer.Middleware(mux))
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if excludedRoutes[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tag := r.URL.Path
+		if mux, ok := next.(*http.ServeMux); ok && TraceID != "" {
+			// resolve pattern before handler for route concurrency (r.Pattern is set while routing)
+			if _, pattern := mux.Handler(r); pattern != "" {
+				tag = pattern
+			}
+		}
+		if excludedRoutes[tag] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p := WebRouteMeasure(tag, r.Method)
+		if nonce := r.Header.Get("X-Request-Nonce"); nonce != "" && p.toFile != nil {
+			p.duplicate = requestNonces.seen(nonce)
+		}
+		var tw *serverTimingWriter
+		if p.toFile != nil {
+			timing := &serverTiming{}
+			ctx := withRequestTags(context.WithValue(r.Context(), serverTimingKey{}, timing))
+			if RequestIDFromContext(ctx) == "" {
+				requestID := r.Header.Get("X-Request-ID")
+				if requestID == "" {
+					requestID = NewRequestID()
+				}
+				ctx = WithRequestID(ctx, requestID)
+				defer ReleaseRequestID(requestID)
+			}
+			r = r.WithContext(ctx)
+			tw = &serverTimingWriter{ResponseWriter: w, timing: timing, startTime: time.Unix(0, p.startTime), measureWrite: config.MeasureResponseBuffering}
+			defer tw.setHeader()
+			w = tw
+		}
+		if isWebSocketUpgrade(r) {
+			var ws *webSocketWriter
+			if ws, r = withWebSocketHandshake(w, r); ws != nil {
+				defer ws.discardHandshake()
+				w = ws
+			}
+		}
+		next.ServeHTTP(w, r)
+		if r.Pattern != "" {
+			if excludedRoutes[r.Pattern] {
+				p.discard()
+				uncountRequest()
+				return
+			}
+			p.SetTag(r.Pattern)
+		}
+		header := w.Header()
+		p.cacheable = header.Get("Cache-Control") != "" || header.Get("ETag") != ""
+		p.cacheHit = strings.HasPrefix(strings.ToUpper(header.Get("X-Cache")), "HIT")
+		if tw != nil {
+			if tw.statusCode >= http.StatusInternalServerError {
+				countRouteError()
+			}
+			p.writeDuration = tw.writeDuration
+			p.requestTags = RequestTags(r.Context())
+		}
+		p.End()
+	})
+}
@@ -0,0 +1,69 @@
+// Package mongotracer is MongoDB Command Tracer for ISUCON Tracer
+//
+//	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetMonitor(mongotracer.NewMongoMonitor()))
+package mongotracer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var mongoLogFile = tracer.NewLogFile("mongo")
+
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+type startedCommand struct {
+	startTime  int64
+	collection string
+}
+
+// NewMongoMonitor make create New MongoDB Command Monitor writing mongo.log
+// Columns: start_ns, duration_ns, command_name, database, collection, success
+func NewMongoMonitor() *event.CommandMonitor {
+	var started sync.Map
+
+	finished := func(e event.CommandFinishedEvent, success bool) {
+		key := commandKey{connectionID: e.ConnectionID, requestID: e.RequestID}
+		v, ok := started.LoadAndDelete(key)
+		if !ok {
+			return
+		}
+		s := v.(startedCommand)
+		mongoLogFile.Printf("%d\t%d\t%s\t%s\t%s\t%t\n", s.startTime, e.Duration.Nanoseconds(), e.CommandName, e.DatabaseName, s.collection, success)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(c context.Context, e *event.CommandStartedEvent) {
+			key := commandKey{connectionID: e.ConnectionID, requestID: e.RequestID}
+			started.Store(key, startedCommand{startTime: time.Now().UnixNano(), collection: collectionName(e.Command)})
+		},
+		Succeeded: func(c context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent, true)
+		},
+		Failed: func(c context.Context, e *event.CommandFailedEvent) {
+			finished(e.CommandFinishedEvent, false)
+		},
+	}
+}
+
+// collectionName extract collection name from command document
+// (e.g. {"find": "users", ...} -> "users")
+func collectionName(command bson.Raw) string {
+	elem, err := command.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	name, ok := elem.Value().StringValueOK()
+	if !ok {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,153 @@
+package tracer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// mutexProfileFraction is rate of mutex contention events reported while Tracer is running
+const mutexProfileFraction = 5
+const lockHotspotCount = 5
+
+// lockHotspot is a call site waiting for locks in summary.json
+type lockHotspot struct {
+	Site        string `json:"site"`
+	DelayNs     int64  `json:"delay_ns"`
+	Contentions int64  `json:"contentions"`
+}
+
+// mutexProfileBase is mutex profile on Start (mutex profile of runtime is cumulative)
+var mutexProfileBase *profile.Profile
+var mutexProfiling bool
+
+func readMutexProfile() (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("mutex").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return profile.Parse(&buf)
+}
+
+func startMutexProfile() {
+	mutexProfiling = config.profileEnabled("mutex")
+	if !mutexProfiling {
+		return
+	}
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+	base, err := readMutexProfile()
+	logError(err)
+	mutexProfileBase = base
+}
+
+// resetMutexProfileBase take current mutex profile as base (Checkpoint)
+func resetMutexProfileBase() {
+	if !mutexProfiling {
+		return
+	}
+	base, err := readMutexProfile()
+	if err != nil {
+		return
+	}
+	mutexProfileBase = base
+}
+
+// mutexProfileDelta return mutex profile since Start
+func mutexProfileDelta() (*profile.Profile, error) {
+	p, err := readMutexProfile()
+	if err != nil || mutexProfileBase == nil {
+		return p, err
+	}
+	base := mutexProfileBase.Copy()
+	base.Scale(-1)
+	if p, err = profile.Merge([]*profile.Profile{p, base}); err != nil {
+		return nil, err
+	}
+	samples := p.Sample[:0]
+	for _, s := range p.Sample {
+		if s.Value[0] != 0 || s.Value[1] != 0 {
+			samples = append(samples, s)
+		}
+	}
+	p.Sample = samples
+	return p, nil
+}
+
+// lockSite return first frame out of sync and runtime packages ("function (file:line)")
+func lockSite(s *profile.Sample) string {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name := line.Function.Name
+			if strings.HasPrefix(name, "sync.") || strings.HasPrefix(name, "runtime.") || strings.HasPrefix(name, "internal/sync.") {
+				continue
+			}
+			return fmt.Sprintf("%s (%s:%d)", name, path.Base(line.Function.Filename), line.Line)
+		}
+	}
+	return "?"
+}
+
+// lockHotspots return top call sites by lock wait time since Start (nil if mutex profile is not enabled)
+func lockHotspots() []lockHotspot {
+	if !mutexProfiling {
+		return nil
+	}
+	p, err := mutexProfileDelta()
+	if err != nil {
+		logError(err)
+		return nil
+	}
+	sites := map[string]*lockHotspot{}
+	for _, s := range p.Sample {
+		site := lockSite(s)
+		h, ok := sites[site]
+		if !ok {
+			h = &lockHotspot{Site: site}
+			sites[site] = h
+		}
+		h.Contentions += s.Value[0]
+		h.DelayNs += s.Value[1]
+	}
+	hotspots := []lockHotspot{}
+	for _, h := range sites {
+		hotspots = append(hotspots, *h)
+	}
+	sort.Slice(hotspots, func(i, j int) bool { return hotspots[i].DelayNs > hotspots[j].DelayNs })
+	if len(hotspots) > lockHotspotCount {
+		hotspots = hotspots[:lockHotspotCount]
+	}
+	return hotspots
+}
+
+// stopMutexProfile write mutex profile since Start to mutex.pprof
+func stopMutexProfile(dirName string) error {
+	if !mutexProfiling {
+		return nil
+	}
+	mutexProfiling = false
+	p, err := mutexProfileDelta()
+	runtime.SetMutexProfileFraction(0)
+	mutexProfileBase = nil
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path.Join(dirName, "mutex.pprof"))
+	if err != nil {
+		return err
+	}
+	if err := p.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,45 @@
+package tracer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const nonceCacheSize = 10000
+const nonceWindow = 60 * time.Second
+
+type nonceEntry struct {
+	nonce string
+	seen  time.Time
+}
+
+// nonceCache is LRU cache of recently seen request nonces
+type nonceCache struct {
+	mutex   sync.Mutex
+	list    *list.List
+	entries map[string]*list.Element
+}
+
+var requestNonces = &nonceCache{list: list.New(), entries: map[string]*list.Element{}}
+
+// seen record nonce and return whether it was seen within nonceWindow
+func (c *nonceCache) seen(nonce string) bool {
+	now := time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, ok := c.entries[nonce]; ok {
+		e := elem.Value.(*nonceEntry)
+		duplicate := now.Sub(e.seen) < nonceWindow
+		e.seen = now
+		c.list.MoveToFront(elem)
+		return duplicate
+	}
+	c.entries[nonce] = c.list.PushFront(&nonceEntry{nonce: nonce, seen: now})
+	if c.list.Len() > nonceCacheSize {
+		oldest := c.list.Back()
+		c.list.Remove(oldest)
+		delete(c.entries, oldest.Value.(*nonceEntry).nonce)
+	}
+	return false
+}
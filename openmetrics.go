@@ -0,0 +1,85 @@
+package tracer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var openMetricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeOpenMetrics write statistics of current trace in OpenMetrics text format
+func writeOpenMetrics(b *bytes.Buffer) {
+	running := 0
+	if TraceID != "" {
+		running = 1
+	}
+	fmt.Fprintf(b, "# TYPE isucon_tracer_running gauge\n")
+	fmt.Fprintf(b, "# HELP isucon_tracer_running 1 if Tracer is running\n")
+	fmt.Fprintf(b, "isucon_tracer_running{trace_id=\"%s\"} %d\n", openMetricsLabelEscaper.Replace(TraceID), running)
+
+	for _, c := range []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"isucon_tracer_sql_queries", "SQL queries since Start", atomic.LoadInt64(&sqlCount)},
+		{"isucon_tracer_perf_measurements", "Measurements by Measure since Start", atomic.LoadInt64(&perfCount)},
+		{"isucon_tracer_route_requests", "Web route measurements since Start", atomic.LoadInt64(&routeCount)},
+		{"isucon_tracer_overflow", "Measurements not recorded because of Config.MaxInFlight", atomic.LoadInt64(&overflowCount)},
+	} {
+		fmt.Fprintf(b, "# TYPE %s counter\n# HELP %s %s\n%s_total %d\n", c.name, c.name, c.help, c.name, c.value)
+	}
+
+	fmt.Fprintf(b, "# TYPE isucon_tracer_sql_in_flight gauge\n")
+	fmt.Fprintf(b, "# HELP isucon_tracer_sql_in_flight SQL queries executing now\n")
+	fmt.Fprintf(b, "isucon_tracer_sql_in_flight %d\n", atomic.LoadInt64(&sqlInFlight))
+
+	stats := Stats()
+	tags := make([]string, 0, len(stats.TagStats))
+	for tag := range stats.TagStats {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	fmt.Fprintf(b, "# TYPE isucon_tracer_duration_seconds summary\n")
+	fmt.Fprintf(b, "# UNIT isucon_tracer_duration_seconds seconds\n")
+	fmt.Fprintf(b, "# HELP isucon_tracer_duration_seconds Latency of measurements per tag\n")
+	for _, tag := range tags {
+		s := stats.TagStats[tag]
+		label := openMetricsLabelEscaper.Replace(tag)
+		fmt.Fprintf(b, "isucon_tracer_duration_seconds{tag=\"%s\",quantile=\"0.95\"} %g\n", label, s.P95.Seconds())
+		fmt.Fprintf(b, "isucon_tracer_duration_seconds{tag=\"%s\",quantile=\"0.99\"} %g\n", label, s.P99.Seconds())
+		fmt.Fprintf(b, "isucon_tracer_duration_seconds_sum{tag=\"%s\"} %g\n", label, s.Mean.Seconds()*float64(s.Count))
+		fmt.Fprintf(b, "isucon_tracer_duration_seconds_count{tag=\"%s\"} %d\n", label, s.Count)
+	}
+
+	routes := make([]string, 0, len(stats.RouteStats))
+	for route := range stats.RouteStats {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	fmt.Fprintf(b, "# TYPE isucon_tracer_route_peak_concurrency gauge\n")
+	fmt.Fprintf(b, "# HELP isucon_tracer_route_peak_concurrency Max number of handlers executing at once per route\n")
+	for _, route := range routes {
+		fmt.Fprintf(b, "isucon_tracer_route_peak_concurrency{route=\"%s\"} %d\n", openMetricsLabelEscaper.Replace(route), stats.RouteStats[route].PeakConcurrency)
+	}
+	b.WriteString("# EOF\n")
+}
+
+// OpenMetricsHandler return http.Handler responding statistics of current trace in OpenMetrics text format
+// It needs no Prometheus client library, and can be scraped by Prometheus or Grafana Alloy.
+//
+//	http.Handle("/metrics", tracer.OpenMetricsHandler())
+func OpenMetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b bytes.Buffer
+		writeOpenMetrics(&b)
+		w.Header().Set("Content-Type", openMetricsContentType)
+		w.Write(b.Bytes())
+	})
+}
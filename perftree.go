@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type perfTreeNode struct {
+	tag      string
+	count    int64
+	total    time.Duration
+	children map[string]*perfTreeNode
+}
+
+var perfTreeMutex sync.Mutex
+var perfTreeRoot = newPerfTreeNode("")
+
+func newPerfTreeNode(tag string) *perfTreeNode {
+	return &perfTreeNode{tag: tag, children: map[string]*perfTreeNode{}}
+}
+
+func resetPerfTree() {
+	perfTreeMutex.Lock()
+	defer perfTreeMutex.Unlock()
+	perfTreeRoot = newPerfTreeNode("")
+}
+
+// addPerfTree add measurement to the node of path (tags from root to the measurement)
+func addPerfTree(path []string, tag string, duration time.Duration) {
+	if len(path) == 0 {
+		path = []string{tag}
+	}
+	perfTreeMutex.Lock()
+	defer perfTreeMutex.Unlock()
+	node := perfTreeRoot
+	for _, t := range path {
+		child, ok := node.children[t]
+		if !ok {
+			child = newPerfTreeNode(t)
+			node.children[t] = child
+		}
+		node = child
+	}
+	node.count++
+	node.total += duration
+}
+
+func (n *perfTreeNode) sortedChildren() []*perfTreeNode {
+	children := make([]*perfTreeNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].total > children[j].total
+	})
+	return children
+}
+
+// fprint write node and its children indented by depth
+// Each line has total time, count and fraction of the parent total time
+func (n *perfTreeNode) fprint(w io.Writer, depth int, parentTotal time.Duration) {
+	fraction := 100.0
+	if parentTotal > 0 {
+		fraction = float64(n.total) / float64(parentTotal) * 100
+	}
+	fmt.Fprintf(w, "%s%s\ttotal=%.3fms\tcount=%d\t%.1f%%\n", strings.Repeat("  ", depth), n.tag, float64(n.total)/float64(time.Millisecond), n.count, fraction)
+	for _, child := range n.sortedChildren() {
+		child.fprint(w, depth+1, n.total)
+	}
+}
+
+func writePerfTree(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+
+	perfTreeMutex.Lock()
+	for _, node := range perfTreeRoot.sortedChildren() {
+		node.fprint(w, 0, 0)
+	}
+	perfTreeMutex.Unlock()
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,34 @@
+package tracer
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+var sqlInFlight int64
+var sqlInFlightPeak int64
+
+func startSQLInFlight() {
+	storeMax(&sqlInFlightPeak, atomic.AddInt64(&sqlInFlight, 1))
+}
+
+func endSQLInFlight() {
+	atomic.AddInt64(&sqlInFlight, -1)
+}
+
+func resetSQLInFlightPeak() {
+	atomic.StoreInt64(&sqlInFlightPeak, atomic.LoadInt64(&sqlInFlight))
+}
+
+// recommendedMaxOpenConns return peak concurrent SQL queries x 1.2 rounded up to power of 2 (0 if no queries)
+func recommendedMaxOpenConns(peak int64) int64 {
+	if peak <= 0 {
+		return 0
+	}
+	target := int64(math.Ceil(float64(peak) * 1.2))
+	n := int64(1)
+	for n < target {
+		n *= 2
+	}
+	return n
+}
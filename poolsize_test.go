@@ -0,0 +1,23 @@
+package tracer
+
+import "testing"
+
+func TestRecommendedMaxOpenConns(t *testing.T) {
+	tests := []struct {
+		peak int64
+		want int64
+	}{
+		{-1, 0},
+		{0, 0},
+		{1, 2},
+		{5, 8},
+		{10, 16},
+		{13, 16},
+		{14, 32},
+	}
+	for _, tt := range tests {
+		if got := recommendedMaxOpenConns(tt.peak); got != tt.want {
+			t.Errorf("recommendedMaxOpenConns(%d) = %d, want %d", tt.peak, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tracer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/pkg/profile"
+)
+
+var profilerMutex sync.Mutex
+var profilerHandle interface{ Stop() }
+var profilerDir string
+
+func startProfiler(dirName string) {
+	profilerMutex.Lock()
+	defer profilerMutex.Unlock()
+	profilerDir = dirName
+	if !config.profileEnabled("cpu") {
+		return
+	}
+	profilerHandle = profile.Start(profile.ProfilePath(dirName), profile.NoShutdownHook)
+}
+
+func stopProfiler() {
+	profilerMutex.Lock()
+	defer profilerMutex.Unlock()
+	if profilerHandle != nil {
+		profilerHandle.Stop()
+		profilerHandle = nil
+	}
+}
+
+// PProfHandler return http.Handler serving CPU profile of current trace
+// CPU profiling is stopped to write cpu.pprof, and restarted after reading it,
+// so cpu.pprof written on Stop covers only after the last request.
+// 404 is returned while Tracer is not running.
+//
+//	http.Handle("/debug/tracer/profile/cpu", tracer.PProfHandler())
+//	go tool pprof http://localhost:8080/debug/tracer/profile/cpu
+func PProfHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		profilerMutex.Lock()
+		if profilerHandle == nil {
+			profilerMutex.Unlock()
+			http.NotFound(w, r)
+			return
+		}
+		profilerHandle.Stop()
+		data, err := ioutil.ReadFile(path.Join(profilerDir, "cpu.pprof"))
+		profilerHandle = profile.Start(profile.ProfilePath(profilerDir), profile.NoShutdownHook)
+		profilerMutex.Unlock()
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="cpu.pprof"`)
+		w.Write(data)
+	})
+}
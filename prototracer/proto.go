@@ -0,0 +1,295 @@
+// Package prototracer is Protocol Buffers Encoder for ISUCON Tracer
+//
+//	tracer.SetConfig(tracer.Config{Encoder: prototracer.ProtoEncoder{}})
+//
+// Records are marshaled by protowire following tracer.proto,
+// so log files can be parsed by ReadLog or by code generated with protoc-gen-go.
+package prototracer
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SchemaVersion is version of tracer.proto written to Header
+const SchemaVersion = 1
+
+// Field numbers of LogFile
+const (
+	logFileHeader protowire.Number = 1
+	logFileSQL    protowire.Number = 2
+	logFilePerf   protowire.Number = 3
+	logFileRoute  protowire.Number = 4
+)
+
+// ProtoEncoder is tracer.Encoder writing length-prefixed LogFile records
+type ProtoEncoder struct{}
+
+// EncodeHeader write Header record with TraceID and SchemaVersion
+func (ProtoEncoder) EncodeHeader(w io.Writer, columns []string) error {
+	var b []byte
+	b = appendString(b, 1, tracer.TraceID)
+	b = appendVarint(b, 2, SchemaVersion)
+	for _, c := range columns {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, c)
+	}
+	return writeRecord(w, logFileHeader, b)
+}
+
+func (ProtoEncoder) EncodeSQL(w io.Writer, e tracer.SQLEntry) error {
+	var b []byte
+	b = appendVarint(b, 1, uint64(e.StartTime))
+	b = appendVarint(b, 2, uint64(e.Duration))
+	b = appendString(b, 3, e.Tag)
+	b = appendString(b, 4, e.Query)
+	b = appendString(b, 5, e.PerfTag)
+	b = appendBool(b, 6, e.Cacheable)
+	b = appendString(b, 7, e.CPUID)
+	return writeRecord(w, logFileSQL, b)
+}
+
+func (ProtoEncoder) EncodePerf(w io.Writer, e tracer.PerfEntry) error {
+	var b []byte
+	b = appendVarint(b, 1, uint64(e.StartTime))
+	b = appendVarint(b, 2, uint64(e.Duration))
+	b = appendString(b, 3, e.Tag)
+	b = appendString(b, 4, e.Text)
+	b = appendString(b, 5, e.Caller)
+	b = appendString(b, 6, e.CPU)
+	return writeRecord(w, logFilePerf, b)
+}
+
+func (ProtoEncoder) EncodeRoute(w io.Writer, e tracer.RouteEntry) error {
+	var b []byte
+	b = appendVarint(b, 1, uint64(e.StartTime))
+	b = appendVarint(b, 2, uint64(e.Duration))
+	b = appendString(b, 3, e.Tag)
+	b = appendString(b, 4, e.Text)
+	b = appendBool(b, 5, e.Cacheable)
+	b = appendBool(b, 6, e.CacheHit)
+	b = appendBool(b, 7, e.Duplicate)
+	b = appendVarint(b, 8, uint64(e.WriteDuration))
+	for _, t := range e.Tags {
+		b = protowire.AppendTag(b, 9, protowire.BytesType)
+		b = protowire.AppendString(b, t)
+	}
+	b = appendString(b, 10, e.Params)
+	return writeRecord(w, logFileRoute, b)
+}
+
+// writeRecord write varint length and LogFile having one field
+func writeRecord(w io.Writer, num protowire.Number, message []byte) error {
+	var record []byte
+	record = protowire.AppendTag(record, num, protowire.BytesType)
+	record = protowire.AppendBytes(record, message)
+	_, err := w.Write(protowire.AppendBytes(nil, record))
+	return err
+}
+
+// Zero values are omitted as proto3
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	return appendVarint(b, num, protowire.EncodeBool(v))
+}
+
+// Log is content of log file written by ProtoEncoder
+type Log struct {
+	TraceID       string
+	SchemaVersion int
+	Columns       []string
+	SQL           []tracer.SQLEntry
+	Perf          []tracer.PerfEntry
+	Route         []tracer.RouteEntry
+}
+
+// ReadLog read all records of log file written by ProtoEncoder
+func ReadLog(r io.Reader) (*Log, error) {
+	br := bufio.NewReader(r)
+	l := &Log{}
+	for {
+		size, err := readUvarint(br)
+		if err == io.EOF {
+			return l, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(br, record); err != nil {
+			return nil, err
+		}
+		if err := l.unmarshal(record); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readUvarint(br *bufio.Reader) (uint64, error) {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		c, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("prototracer: invalid record length")
+}
+
+// field is a decoded field of message
+type field struct {
+	num   protowire.Number
+	value uint64
+	bytes []byte
+}
+
+// parseFields decode fields of message (varint and bytes types, others are skipped)
+func parseFields(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.value, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+func (l *Log) unmarshal(record []byte) error {
+	fields, err := parseFields(record)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		message, err := parseFields(f.bytes)
+		if err != nil {
+			return err
+		}
+		switch f.num {
+		case logFileHeader:
+			for _, m := range message {
+				switch m.num {
+				case 1:
+					l.TraceID = string(m.bytes)
+				case 2:
+					l.SchemaVersion = int(m.value)
+				case 3:
+					l.Columns = append(l.Columns, string(m.bytes))
+				}
+			}
+		case logFileSQL:
+			var e tracer.SQLEntry
+			for _, m := range message {
+				switch m.num {
+				case 1:
+					e.StartTime = int64(m.value)
+				case 2:
+					e.Duration = time.Duration(m.value)
+				case 3:
+					e.Tag = string(m.bytes)
+				case 4:
+					e.Query = string(m.bytes)
+				case 5:
+					e.PerfTag = string(m.bytes)
+				case 6:
+					e.Cacheable = m.value != 0
+				case 7:
+					e.CPUID = string(m.bytes)
+				}
+			}
+			l.SQL = append(l.SQL, e)
+		case logFilePerf:
+			var e tracer.PerfEntry
+			for _, m := range message {
+				switch m.num {
+				case 1:
+					e.StartTime = int64(m.value)
+				case 2:
+					e.Duration = time.Duration(m.value)
+				case 3:
+					e.Tag = string(m.bytes)
+				case 4:
+					e.Text = string(m.bytes)
+				case 5:
+					e.Caller = string(m.bytes)
+				case 6:
+					e.CPU = string(m.bytes)
+				}
+			}
+			l.Perf = append(l.Perf, e)
+		case logFileRoute:
+			var e tracer.RouteEntry
+			for _, m := range message {
+				switch m.num {
+				case 1:
+					e.StartTime = int64(m.value)
+				case 2:
+					e.Duration = time.Duration(m.value)
+				case 3:
+					e.Tag = string(m.bytes)
+				case 4:
+					e.Text = string(m.bytes)
+				case 5:
+					e.Cacheable = m.value != 0
+				case 6:
+					e.CacheHit = m.value != 0
+				case 7:
+					e.Duplicate = m.value != 0
+				case 8:
+					e.WriteDuration = time.Duration(m.value)
+				case 9:
+					e.Tags = append(e.Tags, string(m.bytes))
+				case 10:
+					e.Params = string(m.bytes)
+				}
+			}
+			l.Route = append(l.Route, e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package tracer
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// optimizationCandidateCount is number of query fingerprints in optimization_candidates of summary.json
+const optimizationCandidateCount = 20
+
+// optimizationCandidate is query fingerprint with its cost score
+type optimizationCandidate struct {
+	Fingerprint string  `json:"fingerprint"`
+	Cost        float64 `json:"cost"`
+}
+
+// EstimateQueryCost return cost score of query fingerprint since Start (0 if not executed)
+// Cost is total time (ms) / route requests * count, so that frequent queries get higher priority.
+// Route requests is regarded as 1 if no requests are measured.
+func EstimateQueryCost(fingerprint string) float64 {
+	summaryMutex.Lock()
+	var c summaryCount
+	if p, ok := fingerprintCounts[fingerprint]; ok {
+		c = *p
+	}
+	summaryMutex.Unlock()
+	return queryCost(c.count, c.total.Seconds()*1000)
+}
+
+func queryCost(count int64, totalMs float64) float64 {
+	requests := atomic.LoadInt64(&routeCount)
+	if requests == 0 {
+		requests = 1
+	}
+	return totalMs / float64(requests) * float64(count)
+}
+
+// optimizationCandidates return top 20 query fingerprints by cost score
+func optimizationCandidates(queries []querySummary) []optimizationCandidate {
+	candidates := make([]optimizationCandidate, 0, len(queries))
+	for _, q := range queries {
+		candidates = append(candidates, optimizationCandidate{Fingerprint: q.Fingerprint, Cost: queryCost(q.Count, float64(q.TotalNs)/1e6)})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Cost > candidates[j].Cost })
+	if len(candidates) > optimizationCandidateCount {
+		candidates = candidates[:optimizationCandidateCount]
+	}
+	return candidates
+}
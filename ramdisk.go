@@ -0,0 +1,47 @@
+package tracer
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ramDiskDir is RAM-backed directory detected on Start (Config.AutoDetectRAMDisk)
+var ramDiskDir string
+
+// isWritableDir report whether a file can be created in dirName
+func isWritableDir(dirName string) bool {
+	file, err := ioutil.TempFile(dirName, ".isucon-tracer-")
+	if err != nil {
+		return false
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return true
+}
+
+// detectRAMDisk return first writable tmpfs or ramfs mount point in /proc/mounts ("" if not found)
+func detectRAMDisk() string {
+	data, err := ioutil.ReadFile("/proc/mounts")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// device mount_point fs_type options dump pass
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "tmpfs" && fields[2] != "ramfs") {
+			continue
+		}
+		mountPoint := fields[1]
+		if strings.HasPrefix(mountPoint, "/sys/") || strings.HasPrefix(mountPoint, "/proc/") {
+			continue
+		}
+		if strings.HasPrefix(fields[3], "ro,") || fields[3] == "ro" {
+			continue
+		}
+		if isWritableDir(mountPoint) {
+			return mountPoint
+		}
+	}
+	return ""
+}
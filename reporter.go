@@ -0,0 +1,85 @@
+package tracer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// reporter write one-line statistics every Config.ReportInterval while Tracer is running
+type reporter struct {
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+var activeReporter *reporter
+
+func (c *Config) reportWriter() io.Writer {
+	if c.ReportWriter == nil {
+		return os.Stderr
+	}
+	return c.ReportWriter
+}
+
+func startReporter() {
+	if config.ReportInterval <= 0 {
+		return
+	}
+	r := &reporter{done: make(chan struct{})}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(config.ReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				fmt.Fprintln(config.reportWriter(), reportLine())
+			}
+		}
+	}()
+	activeReporter = r
+}
+
+func stopReporter() {
+	if activeReporter == nil {
+		return
+	}
+	close(activeReporter.done)
+	activeReporter.wg.Wait()
+	activeReporter = nil
+}
+
+// sumSummaryCounts return total count and duration of counts
+func sumSummaryCounts(counts map[string]*summaryCount) (int64, time.Duration) {
+	var count int64
+	var total time.Duration
+	for _, c := range counts {
+		count += c.count
+		total += c.total
+	}
+	return count, total
+}
+
+// reportLine return statistics since Start
+// (e.g. "[tracer] sql: 1234 queries, mean 2.1ms | routes: 567 req, mean 15ms | goroutines: 89")
+func reportLine() string {
+	summaryMutex.Lock()
+	sqlQueries, sqlTotal := sumSummaryCounts(fingerprintCounts)
+	routeRequests, routeTotal := sumSummaryCounts(routeCounts)
+	summaryMutex.Unlock()
+	return fmt.Sprintf("[tracer] sql: %d queries, mean %s | routes: %d req, mean %s | goroutines: %d",
+		sqlQueries, meanMs(sqlTotal, sqlQueries), routeRequests, meanMs(routeTotal, routeRequests), runtime.NumGoroutine())
+}
+
+func meanMs(total time.Duration, count int64) string {
+	if count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", float64(total)/float64(count)/1e6)
+}
@@ -0,0 +1,74 @@
+package tracer
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID make create New Context with Request ID
+// SQL queries executed with this context are counted per Request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// NewRequestID generate random Request ID (UUID version 4)
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// RequestIDFromContext return Request ID stored in Context ("" if not exists)
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+var queryCounts sync.Map
+
+var budgetLogFile *LogFile
+var budgetLogFileOnce sync.Once
+
+func resetQueryCounts() {
+	queryCounts.Range(func(key, value interface{}) bool {
+		queryCounts.Delete(key)
+		return true
+	})
+}
+
+// ReleaseRequestID delete SQL query count of Request ID (call when the request ends)
+// Middleware and router integrations setting Request ID call it.
+func ReleaseRequestID(requestID string) {
+	queryCounts.Delete(requestID)
+}
+
+// checkQueryBudget count SQL query of the request and report when it exceeds MaxQueriesPerRequest
+// Exceeded requests are written to budget.log (time_ns, request_id, count), not to sql.log.
+func checkQueryBudget(ctx context.Context) {
+	if config.MaxQueriesPerRequest <= 0 {
+		return
+	}
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		return
+	}
+	v, _ := queryCounts.LoadOrStore(requestID, new(int64))
+	count := int(atomic.AddInt64(v.(*int64), 1))
+	if count != config.MaxQueriesPerRequest+1 {
+		return
+	}
+	budgetLogFileOnce.Do(func() {
+		budgetLogFile = NewLogFile("budget")
+	})
+	budgetLogFile.Printf("%d\t%s\t%d\n", now().UnixNano(), requestID, count)
+	if config.OnBudgetExceeded != nil {
+		config.OnBudgetExceeded(requestID, count)
+	}
+}
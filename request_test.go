@@ -0,0 +1,47 @@
+package tracer
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestCheckQueryBudget(t *testing.T) {
+	dir := t.TempDir()
+	var exceeded []int
+	SetConfig(Config{LogDir: dir, MaxQueriesPerRequest: 2, OnBudgetExceeded: func(requestID string, count int) {
+		exceeded = append(exceeded, count)
+	}})
+	defer SetConfig(Config{})
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithRequestID(context.Background(), "req-1")
+	for i := 0; i < 4; i++ {
+		checkQueryBudget(ctx)
+	}
+	ReleaseRequestID("req-1")
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exceeded) != 1 || exceeded[0] != 3 {
+		t.Errorf("OnBudgetExceeded counts = %v, want [3]", exceeded)
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, "budget.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "\treq-1\t3\n") {
+		t.Errorf("budget.log = %q, want req-1 with count 3", data)
+	}
+	entries, err := ReadSQLLog(path.Join(dir, "sql.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("sql.log entries = %+v, want none", entries)
+	}
+}
@@ -0,0 +1,46 @@
+package tracer
+
+import (
+	"context"
+	"sync"
+)
+
+type requestTagsKey struct{}
+
+// requestTags is mutable holder of tags, so that Middleware can read tags added in derived Contexts
+type requestTags struct {
+	mutex sync.Mutex
+	tags  []string
+}
+
+// TagRequest add user-defined labels to the request (written to tags column of webroute.log by Middleware)
+// Context returned is same as ctx if ctx is request Context of Middleware.
+//
+//	tracer.TagRequest(r.Context(), "admin", "bulk_operation")
+func TagRequest(ctx context.Context, tags ...string) context.Context {
+	holder, ok := ctx.Value(requestTagsKey{}).(*requestTags)
+	if !ok {
+		holder = &requestTags{}
+		ctx = context.WithValue(ctx, requestTagsKey{}, holder)
+	}
+	holder.mutex.Lock()
+	holder.tags = append(holder.tags, tags...)
+	holder.mutex.Unlock()
+	return ctx
+}
+
+// RequestTags return labels added by TagRequest
+func RequestTags(ctx context.Context) []string {
+	holder, ok := ctx.Value(requestTagsKey{}).(*requestTags)
+	if !ok {
+		return nil
+	}
+	holder.mutex.Lock()
+	defer holder.mutex.Unlock()
+	return append([]string(nil), holder.tags...)
+}
+
+// withRequestTags return Context holding empty tags for Middleware
+func withRequestTags(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestTagsKey{}, &requestTags{})
+}
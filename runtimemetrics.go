@@ -0,0 +1,38 @@
+package tracer
+
+import "runtime/metrics"
+
+// runtimeMetricNames are runtime/metrics written to summary.json on Stop
+var runtimeMetricNames = []string{
+	"/gc/cycles/total:gc-cycles",
+	"/gc/cycles/forced:gc-cycles",
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+	"/gc/heap/goal:bytes",
+	"/gc/heap/objects:objects",
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/total:bytes",
+	"/sched/goroutines:goroutines",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/cpu/classes/total:cpu-seconds",
+}
+
+// readRuntimeMetrics read runtimeMetricNames (metrics not supported by Go runtime are omitted)
+func readRuntimeMetrics() map[string]interface{} {
+	samples := make([]metrics.Sample, len(runtimeMetricNames))
+	for i, name := range runtimeMetricNames {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	values := map[string]interface{}{}
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			values[s.Name] = s.Value.Uint64()
+		case metrics.KindFloat64:
+			values[s.Name] = s.Value.Float64()
+		}
+	}
+	return values
+}
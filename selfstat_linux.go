@@ -0,0 +1,33 @@
+package tracer
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ of /proc/self/stat (100 on almost all Linux systems)
+const clockTicks = 100
+
+// readSelfStat return CPU time (user + system) and resident set size (bytes) of this process from /proc/self/stat
+func readSelfStat() (time.Duration, int64) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, 0
+	}
+	// comm (2nd field) may contain spaces, fields after ")" start from state (3rd field)
+	s := string(data)
+	pos := strings.LastIndex(s, ")")
+	if pos < 0 {
+		return 0, 0
+	}
+	fields := strings.Fields(s[pos+1:])
+	if len(fields) < 22 {
+		return 0, 0
+	}
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	return time.Duration(utime+stime) * time.Second / clockTicks, rss * int64(os.Getpagesize())
+}
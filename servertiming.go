@@ -0,0 +1,91 @@
+package tracer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type serverTimingKey struct{}
+
+// serverTiming is total duration of SQL queries executed with request Context
+type serverTiming struct {
+	dbNs int64
+}
+
+func addServerTiming(ctx context.Context, d time.Duration) {
+	if st, ok := ctx.Value(serverTimingKey{}).(*serverTiming); ok {
+		atomic.AddInt64(&st.dbNs, int64(d))
+	}
+}
+
+// serverTimingWriter set Server-Timing header just before response header is written
+type serverTimingWriter struct {
+	http.ResponseWriter
+	timing      *serverTiming
+	startTime   time.Time
+	wroteHeader bool
+	statusCode  int
+	// measureWrite sum time of Write and Flush into writeDuration (Config.MeasureResponseBuffering)
+	measureWrite  bool
+	writeDuration time.Duration
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	db := float64(atomic.LoadInt64(&w.timing.dbNs)) / 1e6
+	handler := float64(time.Since(w.startTime)) / 1e6
+	w.Header().Add("Server-Timing", fmt.Sprintf("db;dur=%.1f,handler;dur=%.1f", db, handler))
+}
+
+func (w *serverTimingWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+	}
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *serverTimingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.statusCode = http.StatusOK
+	}
+	w.setHeader()
+	if !w.measureWrite {
+		return w.ResponseWriter.Write(b)
+	}
+	start := now()
+	n, err := w.ResponseWriter.Write(b)
+	w.writeDuration += now().Sub(start)
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *serverTimingWriter) Flush() {
+	w.setHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		start := now()
+		f.Flush()
+		if w.measureWrite {
+			w.writeDuration += now().Sub(start)
+		}
+	}
+}
+
+// Hijack implements http.Hijacker (used by WebSocket upgraders)
+func (w *serverTimingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap is used by http.ResponseController
+func (w *serverTimingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
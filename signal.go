@@ -0,0 +1,81 @@
+package tracer
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var signalCh = make(chan os.Signal, 1)
+
+func (c *Config) startSignals() []os.Signal {
+	if c.StartSignal != nil {
+		return []os.Signal{c.StartSignal}
+	}
+	return []os.Signal{syscall.SIGUSR1}
+}
+
+func (c *Config) stopSignals() []os.Signal {
+	if c.StopSignal != nil {
+		return []os.Signal{c.StopSignal}
+	}
+	return []os.Signal{syscall.SIGUSR2, syscall.SIGHUP}
+}
+
+func (c *Config) rotateSignals() []os.Signal {
+	if c.RotateSignal != nil {
+		return []os.Signal{c.RotateSignal}
+	}
+	return nil
+}
+
+var exitSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+
+// notifySignals (re)register signals of current Config (signals no longer used are released)
+func notifySignals() {
+	signal.Stop(signalCh)
+	var signals []os.Signal
+	signals = append(signals, config.startSignals()...)
+	signals = append(signals, config.stopSignals()...)
+	signals = append(signals, config.rotateSignals()...)
+	signals = append(signals, exitSignals...)
+	signal.Notify(signalCh, signals...)
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSignal Start, Stop, rotate (Start new trace if running) or exit by signal
+func handleSignal(sig os.Signal) {
+	switch {
+	case containsSignal(config.startSignals(), sig):
+		logError(Start())
+	case containsSignal(config.stopSignals(), sig):
+		logError(Stop())
+	case containsSignal(config.rotateSignals(), sig):
+		if TraceID != "" {
+			logError(Start())
+		}
+	case containsSignal(exitSignals, sig) && config.PropagateSignals:
+		propagateSignal(sig)
+	case containsSignal(exitSignals, sig):
+		stopAndExit()
+	}
+}
+
+// propagateSignal Stop Tracer, release signals and re-send sig to this process (Config.PropagateSignals)
+// Signal handlers of the application receive sig again (signal.Notify delivers to every channel),
+// or default action of sig (exit) is taken if there is none.
+func propagateSignal(sig os.Signal) {
+	stopWithTimeout()
+	signal.Stop(signalCh)
+	if s, ok := sig.(syscall.Signal); ok {
+		logError(syscall.Kill(os.Getpid(), s))
+	}
+}
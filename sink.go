@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Sink receives measurements while Tracer is running (in addition to log files)
+type Sink interface {
+	WriteSQL(e SQLEntry)
+	WritePerf(e PerfEntry)
+	WriteRoute(e RouteEntry)
+}
+
+// flushSinks flush Sinks which buffer measurements (Sinks having Flush() error method)
+func flushSinks() error {
+	var errs []error
+	for _, s := range config.Sinks {
+		if f, ok := s.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type slogSink struct {
+	logger *slog.Logger
+}
+
+// SlogBackend make create New Sink writing measurements to slog.Logger
+func SlogBackend(logger *slog.Logger) Sink {
+	return &slogSink{logger: logger}
+}
+
+func (s *slogSink) WriteSQL(e SQLEntry) {
+	s.logger.LogAttrs(context.Background(), slog.LevelInfo, "sql",
+		slog.String("trace_id", TraceID),
+		slog.Time("start", time.Unix(0, e.StartTime)),
+		slog.Duration("duration", e.Duration),
+		slog.String("tag", e.Tag),
+		slog.String("query", e.Query),
+	)
+}
+
+func (s *slogSink) WritePerf(e PerfEntry) {
+	s.logger.LogAttrs(context.Background(), slog.LevelInfo, "perf",
+		slog.String("trace_id", TraceID),
+		slog.Time("start", time.Unix(0, e.StartTime)),
+		slog.Duration("duration", e.Duration),
+		slog.String("tag", e.Tag),
+		slog.String("text", e.Text),
+	)
+}
+
+func (s *slogSink) WriteRoute(e RouteEntry) {
+	s.logger.LogAttrs(context.Background(), slog.LevelInfo, "route",
+		slog.String("trace_id", TraceID),
+		slog.Time("start", time.Unix(0, e.StartTime)),
+		slog.Duration("duration", e.Duration),
+		slog.String("tag", e.Tag),
+		slog.String("text", e.Text),
+	)
+}
@@ -0,0 +1,105 @@
+package tracer
+
+import (
+	"sort"
+	"time"
+)
+
+// FingerprintDelta is difference of a query fingerprint between two sql.log files
+type FingerprintDelta struct {
+	Fingerprint string
+	BeforeCount int64
+	AfterCount  int64
+	CountDelta  int64
+	BeforeMean  time.Duration
+	AfterMean   time.Duration
+	MeanDelta   time.Duration
+	BeforeTotal time.Duration
+	AfterTotal  time.Duration
+	TotalDelta  time.Duration
+}
+
+// SQLDiff is result of DiffSQLLogs
+type SQLDiff struct {
+	Added     []FingerprintDelta // only in after
+	Removed   []FingerprintDelta // only in before (e.g. N+1 queries removed)
+	Improved  []FingerprintDelta // total duration decreased
+	Regressed []FingerprintDelta // total duration increased
+}
+
+// DiffSQLLogs compare two sql.log files grouped by query fingerprint
+// Each slice is sorted in descending order of absolute total duration delta.
+func DiffSQLLogs(before string, after string) (SQLDiff, error) {
+	var diff SQLDiff
+	b, err := readSQLLogCounts(before)
+	if err != nil {
+		return diff, err
+	}
+	a, err := readSQLLogCounts(after)
+	if err != nil {
+		return diff, err
+	}
+
+	fingerprints := map[string]bool{}
+	for fingerprint := range b {
+		fingerprints[fingerprint] = true
+	}
+	for fingerprint := range a {
+		fingerprints[fingerprint] = true
+	}
+	for _, fingerprint := range sortedKeys(fingerprints) {
+		bc, inBefore := b[fingerprint]
+		ac, inAfter := a[fingerprint]
+		d := FingerprintDelta{Fingerprint: fingerprint}
+		if inBefore {
+			d.BeforeCount, d.BeforeTotal, d.BeforeMean = bc.count, bc.total, bc.total/time.Duration(bc.count)
+		}
+		if inAfter {
+			d.AfterCount, d.AfterTotal, d.AfterMean = ac.count, ac.total, ac.total/time.Duration(ac.count)
+		}
+		d.CountDelta = d.AfterCount - d.BeforeCount
+		d.MeanDelta = d.AfterMean - d.BeforeMean
+		d.TotalDelta = d.AfterTotal - d.BeforeTotal
+		switch {
+		case !inBefore:
+			diff.Added = append(diff.Added, d)
+		case !inAfter:
+			diff.Removed = append(diff.Removed, d)
+		case d.TotalDelta < 0:
+			diff.Improved = append(diff.Improved, d)
+		case d.TotalDelta > 0:
+			diff.Regressed = append(diff.Regressed, d)
+		}
+	}
+
+	for _, deltas := range [][]FingerprintDelta{diff.Added, diff.Removed, diff.Improved, diff.Regressed} {
+		sort.SliceStable(deltas, func(i, j int) bool { return absDuration(deltas[i].TotalDelta) > absDuration(deltas[j].TotalDelta) })
+	}
+	return diff, nil
+}
+
+func readSQLLogCounts(fileName string) (map[string]*summaryCount, error) {
+	entries, err := ReadSQLLog(fileName)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]*summaryCount{}
+	for _, e := range entries {
+		fingerprint := Fingerprint(e.Query)
+		c, ok := counts[fingerprint]
+		if !ok {
+			c = &summaryCount{}
+			counts[fingerprint] = c
+		}
+		c.count++
+		c.total += e.Duration
+	}
+	return counts, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,49 @@
+package tracer
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDiffSQLLogs(t *testing.T) {
+	dir := t.TempDir()
+	before := path.Join(dir, "before.log")
+	after := path.Join(dir, "after.log")
+	if err := ioutil.WriteFile(before, []byte("# start_ns\tduration_ns\ttag\tquery\n"+
+		"1\t100\tQuery\tSELECT * FROM users WHERE id = 1\n"+
+		"2\t100\tQuery\tSELECT * FROM users WHERE id = 2\n"+
+		"3\t50\tQuery\tSELECT * FROM posts\n"+
+		"4\t30\tExec\tDELETE FROM sessions\n"+
+		"5\t0\tMARK\tcheckpoint\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(after, []byte("# start_ns\tduration_ns\ttag\tquery\n"+
+		"1\t80\tQuery\tSELECT * FROM users WHERE id IN (1, 2)\n"+
+		"2\t90\tQuery\tSELECT * FROM posts\n"+
+		"3\t10\tExec\tDELETE FROM sessions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffSQLLogs(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(name string, deltas []FingerprintDelta, fingerprint string, totalDelta time.Duration) {
+		t.Helper()
+		if len(deltas) != 1 || deltas[0].Fingerprint != fingerprint || deltas[0].TotalDelta != totalDelta {
+			t.Errorf("%s = %+v, want %q with TotalDelta %v", name, deltas, fingerprint, totalDelta)
+		}
+	}
+	check("Added", diff.Added, "SELECT * FROM users WHERE id IN (...)", 80)
+	check("Removed", diff.Removed, "SELECT * FROM users WHERE id = ?", -200)
+	check("Improved", diff.Improved, "DELETE FROM sessions", -20)
+	check("Regressed", diff.Regressed, "SELECT * FROM posts", 40)
+	if len(diff.Removed) != 1 {
+		return
+	}
+	if d := diff.Removed[0]; d.BeforeCount != 2 || d.BeforeMean != 100 || d.CountDelta != -2 {
+		t.Errorf("Removed[0] = %+v, want BeforeCount 2, BeforeMean 100, CountDelta -2", d)
+	}
+}
@@ -0,0 +1,140 @@
+package tracer
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TracerStats is statistics of current trace
+type TracerStats struct {
+	TagStats   map[string]TagStat
+	HostStats  map[string]HostStat
+	RouteStats map[string]RouteStat
+	// OverflowCount is number of measurements not recorded because of Config.MaxInFlight
+	OverflowCount int64
+}
+
+// TagStat is latency statistics of measurements (Measure, WebRouteMeasure) per tag
+type TagStat struct {
+	Count int64
+	Mean  time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// Percentiles are estimated by DDSketch-like log-scale buckets (relative error 2%)
+const sketchRelativeAccuracy = 0.02
+const sketchBucketCount = 1024
+
+var sketchGamma = (1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy)
+var sketchLogGamma = math.Log(sketchGamma)
+
+// tagSketch is updated only by atomic operations, so it can be read without lock
+type tagSketch struct {
+	count   int64
+	sum     int64
+	max     int64
+	buckets [sketchBucketCount]int64
+}
+
+func sketchIndex(d time.Duration) int {
+	if d <= 1 {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(d)) / sketchLogGamma))
+	if i >= sketchBucketCount {
+		return sketchBucketCount - 1
+	}
+	return i
+}
+
+func sketchValue(i int) time.Duration {
+	if i == 0 {
+		return 1
+	}
+	return time.Duration(2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1))
+}
+
+func (s *tagSketch) add(d time.Duration) {
+	atomic.AddInt64(&s.buckets[sketchIndex(d)], 1)
+	atomic.AddInt64(&s.sum, int64(d))
+	atomic.AddInt64(&s.count, 1)
+	for {
+		max := atomic.LoadInt64(&s.max)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&s.max, max, int64(d)) {
+			break
+		}
+	}
+}
+
+func (s *tagSketch) quantile(q float64, count int64) time.Duration {
+	rank := int64(math.Ceil(q * float64(count)))
+	var n int64
+	for i := range s.buckets {
+		n += atomic.LoadInt64(&s.buckets[i])
+		if n >= rank {
+			if max := time.Duration(atomic.LoadInt64(&s.max)); sketchValue(i) > max {
+				return max
+			}
+			return sketchValue(i)
+		}
+	}
+	return time.Duration(atomic.LoadInt64(&s.max))
+}
+
+func (s *tagSketch) stat() TagStat {
+	count := atomic.LoadInt64(&s.count)
+	if count == 0 {
+		return TagStat{}
+	}
+	return TagStat{
+		Count: count,
+		Mean:  time.Duration(atomic.LoadInt64(&s.sum) / count),
+		P95:   s.quantile(0.95, count),
+		P99:   s.quantile(0.99, count),
+		Max:   time.Duration(atomic.LoadInt64(&s.max)),
+	}
+}
+
+var tagSketches sync.Map
+
+func addTagStat(tag string, d time.Duration) {
+	v, ok := tagSketches.Load(tag)
+	if !ok {
+		v, _ = tagSketches.LoadOrStore(tag, &tagSketch{})
+	}
+	v.(*tagSketch).add(d)
+}
+
+func resetTagStats() {
+	tagSketches.Range(func(key, value interface{}) bool {
+		tagSketches.Delete(key)
+		return true
+	})
+}
+
+// Stats return statistics of current trace
+func Stats() TracerStats {
+	stats := TracerStats{
+		TagStats:      map[string]TagStat{},
+		HostStats:     map[string]HostStat{},
+		RouteStats:    map[string]RouteStat{},
+		OverflowCount: atomic.LoadInt64(&overflowCount),
+	}
+	tagSketches.Range(func(key, value interface{}) bool {
+		stats.TagStats[key.(string)] = value.(*tagSketch).stat()
+		return true
+	})
+	hostCounters.Range(func(key, value interface{}) bool {
+		stats.HostStats[key.(string)] = value.(*hostCounter).stat()
+		return true
+	})
+	routeConcurrencies.Range(func(key, value interface{}) bool {
+		stats.RouteStats[key.(string)] = RouteStat{PeakConcurrency: atomic.LoadInt64(&value.(*routeConcurrency).peak)}
+		return true
+	})
+	return stats
+}
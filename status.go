@@ -0,0 +1,65 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// routeStatus is rolling percentiles of a route tag in status response
+type routeStatus struct {
+	Count    int     `json:"count"`
+	MedianMs float64 `json:"median_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+}
+
+// statusInFlightTop is number of in-flight measurements in status response
+const statusInFlightTop = 10
+
+// inFlightStatus is a measurement which End is not called yet in status response
+type inFlightStatus struct {
+	Tag       string  `json:"tag"`
+	Text      string  `json:"text"`
+	StartNs   int64   `json:"start_ns"`
+	ElapsedMs float64 `json:"elapsed_ms"`
+}
+
+// status is response of StatusHandler
+type status struct {
+	TraceID  string                 `json:"trace_id"`
+	Running  bool                   `json:"running"`
+	Routes   map[string]routeStatus `json:"routes"`
+	InFlight []inFlightStatus       `json:"in_flight"` // longest-running first (InFlightTop)
+}
+
+func buildStatus() status {
+	s := status{TraceID: TraceID, Running: TraceID != "", Routes: map[string]routeStatus{}, InFlight: []inFlightStatus{}}
+	for _, e := range InFlightTop(statusInFlightTop) {
+		s.InFlight = append(s.InFlight, inFlightStatus{Tag: e.Tag, Text: e.Text, StartNs: e.StartTime, ElapsedMs: float64(e.Duration) / 1e6})
+	}
+	routeWindows.Range(func(key, value interface{}) bool {
+		w := value.(*SlidingWindow)
+		s.Routes[key.(string)] = routeStatus{
+			Count:    w.Len(),
+			MedianMs: float64(w.Median()) / 1e6,
+			P95Ms:    float64(w.P95()) / 1e6,
+			P99Ms:    float64(w.P99()) / 1e6,
+		}
+		return true
+	})
+	return s
+}
+
+// StatusHandler return http.Handler responding status of Tracer in JSON
+// Routes have rolling percentiles of last 1000 measurements per route tag,
+// and in_flight has 10 longest-running measurements which End is not called yet.
+//
+//	http.Handle("/debug/tracer/status", tracer.StatusHandler())
+func StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(buildStatus())
+	})
+}
@@ -0,0 +1,36 @@
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusHandlerInFlight(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	SetConfig(Config{MemoryOnly: true, Clock: clock})
+	defer SetConfig(Config{})
+	if err := Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer Stop()
+
+	p := Measure("slow", "id=1")
+	defer p.End()
+	clock.t = clock.t.Add(2 * time.Second)
+
+	w := httptest.NewRecorder()
+	StatusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/tracer/status", nil))
+	var s status
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.InFlight) != 1 {
+		t.Fatalf("in_flight = %+v, want 1 entry", s.InFlight)
+	}
+	if e := s.InFlight[0]; e.Tag != "slow" || e.Text != "id=1" || e.ElapsedMs != 2000 {
+		t.Errorf("in_flight[0] = %+v, want slow, id=1, 2000ms", e)
+	}
+}
@@ -0,0 +1,221 @@
+package tracer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var traceStartTime time.Time
+var startMappedMemory int64
+var startProcessCPU time.Duration
+var startProcessRSS int64
+var sqlCount int64
+var perfCount int64
+var routeCount int64
+
+type summaryCount struct {
+	count int64
+	total time.Duration
+}
+
+var summaryMutex sync.Mutex
+var fingerprintCounts = map[string]*summaryCount{}
+var routeCounts = map[string]*summaryCount{}
+
+// summary is contents of summary.json written on Stop
+type summary struct {
+	TraceID                 string                  `json:"trace_id"`
+	StartTime               time.Time               `json:"start_time"`
+	EndTime                 time.Time               `json:"end_time"`
+	SQLCount                int64                   `json:"sql_count"`
+	PerfCount               int64                   `json:"perf_count"`
+	RouteCount              int64                   `json:"route_count"`
+	MappedMemoryDeltaBytes  int64                   `json:"mapped_memory_delta_bytes"` // file-backed mappings (Linux only)
+	ProcessCPUSeconds       float64                 `json:"process_cpu_seconds"`       // user + system CPU time of this process (Linux only)
+	ProcessRSSDeltaBytes    int64                   `json:"process_rss_delta_bytes"`   // resident set size of this process (Linux only)
+	TCPEstablishedPeak      int64                   `json:"tcp_established_peak"`      // Config.TrackTCPConnections
+	TCPTimeWaitPeak         int64                   `json:"tcp_time_wait_peak"`        // Config.TrackTCPConnections
+	TCPCloseWaitPeak        int64                   `json:"tcp_close_wait_peak"`       // Config.TrackTCPConnections
+	RuntimeMetrics          map[string]interface{}  `json:"runtime_metrics"`           // runtime/metrics on Stop
+	LockHotspots            []lockHotspot           `json:"lock_hotspots,omitempty"`   // Config.Profiles has "mutex"
+	Metadata                map[string]string       `json:"metadata"`                  // SetMeta
+	SQLConcurrencyPeak      int64                   `json:"sql_concurrency_peak"`
+	RecommendedMaxOpenConns int64                   `json:"recommended_max_open_conns"` // sql_concurrency_peak x 1.2 rounded up to power of 2
+	DBPoolPeakOpen          int                     `json:"db_pool_peak_open"`          // DBs opened by Open
+	DBPoolPeakInUse         int                     `json:"db_pool_peak_in_use"`        // DBs opened by Open
+	DBPoolWaitCount         int64                   `json:"db_pool_wait_count"`         // DBs opened by Open
+	DBPoolWaitNs            int64                   `json:"db_pool_wait_ns"`            // DBs opened by Open
+	FDLeakCount             int                     `json:"fd_leak_count"`              // growth of open file descriptors (Linux only)
+	Warnings                []string                `json:"warnings,omitempty"`
+	OptimizationCandidates  []optimizationCandidate `json:"optimization_candidates"` // top 20 by EstimateQueryCost
+	QueryFrequencyHistogram []frequencyBucket       `json:"query_frequency_histogram"`
+	Queries                 []querySummary          `json:"queries"`
+	Routes                  []routeSummary          `json:"routes"`
+}
+
+// querySummary is SQL statistics per query fingerprint
+type querySummary struct {
+	Fingerprint string `json:"fingerprint"`
+	Count       int64  `json:"count"`
+	TotalNs     int64  `json:"total_ns"`
+	MeanNs      int64  `json:"mean_ns"`
+}
+
+// routeSummary is web route statistics per route tag
+type routeSummary struct {
+	Tag     string `json:"tag"`
+	Count   int64  `json:"count"`
+	TotalNs int64  `json:"total_ns"`
+	MeanNs  int64  `json:"mean_ns"`
+	P99Ns   int64  `json:"p99_ns"`
+	// PeakConcurrency is max number of handlers executing at once (high with high latency suggests lock or rate limit)
+	PeakConcurrency int64 `json:"peak_concurrency"`
+}
+
+func resetSummary(startTime time.Time) {
+	traceStartTime = startTime
+	startMappedMemory = readMappedMemory()
+	startProcessCPU, startProcessRSS = readSelfStat()
+	resetTCPPeaks()
+	atomic.StoreInt64(&sqlCount, 0)
+	atomic.StoreInt64(&perfCount, 0)
+	atomic.StoreInt64(&routeCount, 0)
+	summaryMutex.Lock()
+	fingerprintCounts = map[string]*summaryCount{}
+	routeCounts = map[string]*summaryCount{}
+	summaryMutex.Unlock()
+}
+
+// addSummaryCount add duration to counts map (dereferenced with summaryMutex held, as resetSummary replaces the map)
+func addSummaryCount(counts *map[string]*summaryCount, key string, d time.Duration) {
+	summaryMutex.Lock()
+	defer summaryMutex.Unlock()
+	c, ok := (*counts)[key]
+	if !ok {
+		c = &summaryCount{}
+		(*counts)[key] = c
+	}
+	c.count++
+	c.total += d
+}
+
+func addSQLSummary(e SQLEntry) {
+	addSummaryCount(&fingerprintCounts, Fingerprint(e.Query), e.Duration)
+}
+
+func addRouteSummary(e RouteEntry) {
+	addSummaryCount(&routeCounts, e.Tag, e.Duration)
+}
+
+func buildSummary() summary {
+	processCPU, processRSS := readSelfStat()
+	sqlPeak := atomic.LoadInt64(&sqlInFlightPeak)
+	dbPoolWaitCount, dbPoolWait := dbPoolWaits()
+	dbPoolPeaksMutex.Lock()
+	dbPoolPeakOpen, dbPoolPeakInUse := dbPoolPeak.openConnections, dbPoolPeak.inUse
+	dbPoolPeaksMutex.Unlock()
+	s := summary{
+		TraceID:                 TraceID,
+		StartTime:               traceStartTime,
+		EndTime:                 now(),
+		SQLCount:                atomic.LoadInt64(&sqlCount),
+		PerfCount:               atomic.LoadInt64(&perfCount),
+		RouteCount:              atomic.LoadInt64(&routeCount),
+		MappedMemoryDeltaBytes:  readMappedMemory() - startMappedMemory,
+		ProcessCPUSeconds:       (processCPU - startProcessCPU).Seconds(),
+		ProcessRSSDeltaBytes:    processRSS - startProcessRSS,
+		TCPEstablishedPeak:      atomic.LoadInt64(&tcpPeaks.established),
+		TCPTimeWaitPeak:         atomic.LoadInt64(&tcpPeaks.timeWait),
+		TCPCloseWaitPeak:        atomic.LoadInt64(&tcpPeaks.closeWait),
+		RuntimeMetrics:          readRuntimeMetrics(),
+		LockHotspots:            lockHotspots(),
+		Metadata:                copyMeta(),
+		SQLConcurrencyPeak:      sqlPeak,
+		RecommendedMaxOpenConns: recommendedMaxOpenConns(sqlPeak),
+		DBPoolPeakOpen:          dbPoolPeakOpen,
+		DBPoolPeakInUse:         dbPoolPeakInUse,
+		DBPoolWaitCount:         dbPoolWaitCount,
+		DBPoolWaitNs:            int64(dbPoolWait),
+		FDLeakCount:             fdLeakCount(),
+		Queries:                 querySummaries(),
+		Routes:                  []routeSummary{},
+	}
+
+	s.OptimizationCandidates = optimizationCandidates(s.Queries)
+	s.QueryFrequencyHistogram = queryFrequencyHistogram(s.Queries)
+	if w := httpClientReuseWarning(); w != "" {
+		s.Warnings = append(s.Warnings, w)
+	}
+	if w := fdLeakWarning(s.FDLeakCount); w != "" {
+		s.Warnings = append(s.Warnings, w)
+	}
+
+	summaryMutex.Lock()
+	for tag, c := range routeCounts {
+		r := routeSummary{Tag: tag, Count: c.count, TotalNs: int64(c.total), MeanNs: int64(c.total) / c.count}
+		if v, ok := tagSketches.Load(tag); ok {
+			r.P99Ns = int64(v.(*tagSketch).stat().P99)
+		}
+		r.PeakConcurrency = routePeakConcurrency(tag)
+		s.Routes = append(s.Routes, r)
+	}
+	summaryMutex.Unlock()
+
+	sort.Slice(s.Routes, func(i, j int) bool { return s.Routes[i].TotalNs > s.Routes[j].TotalNs })
+	return s
+}
+
+// querySummaries return SQL statistics per query fingerprint in descending order of total time
+func querySummaries() []querySummary {
+	queries := []querySummary{}
+	summaryMutex.Lock()
+	for fingerprint, c := range fingerprintCounts {
+		queries = append(queries, querySummary{Fingerprint: fingerprint, Count: c.count, TotalNs: int64(c.total), MeanNs: int64(c.total) / c.count})
+	}
+	summaryMutex.Unlock()
+	sort.Slice(queries, func(i, j int) bool { return queries[i].TotalNs > queries[j].TotalNs })
+	return queries
+}
+
+// frequencyBucket is number of query fingerprints executed Min to Max times (Max 0 is unlimited)
+type frequencyBucket struct {
+	Min          int64 `json:"min"`
+	Max          int64 `json:"max"`
+	Fingerprints int   `json:"fingerprints"`
+}
+
+// queryFrequencyHistogram count query fingerprints by execution count (1, 2-10, 11-100, 101-1000, over 1000)
+func queryFrequencyHistogram(queries []querySummary) []frequencyBucket {
+	buckets := []frequencyBucket{{Min: 1, Max: 1}, {Min: 2, Max: 10}, {Min: 11, Max: 100}, {Min: 101, Max: 1000}, {Min: 1001}}
+	for _, q := range queries {
+		for i := range buckets {
+			if buckets[i].Max == 0 || q.Count <= buckets[i].Max {
+				buckets[i].Fingerprints++
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+func writeSummary(fileName string) error {
+	data, err := json.MarshalIndent(buildSummary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+func readSummary(fileName string) (summary, error) {
+	var s summary
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
@@ -0,0 +1,44 @@
+package tracer
+
+import "strings"
+
+// TagNormalizer rewrite tag of measurements (Config.TagNormalizers)
+type TagNormalizer func(tag string) string
+
+// PathParamNormalizer make create TagNormalizer replacing numeric path segments with ":id"
+//
+//	/users/123/posts -> /users/:id/posts
+func PathParamNormalizer() TagNormalizer {
+	return func(tag string) string {
+		if !strings.Contains(tag, "/") {
+			return tag
+		}
+		segments := strings.Split(tag, "/")
+		for i, s := range segments {
+			if isDigits(s) {
+				segments[i] = ":id"
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTag pass tag through Config.TagNormalizers in order
+func normalizeTag(tag string) string {
+	for _, n := range config.TagNormalizers {
+		tag = n(tag)
+	}
+	return tag
+}
@@ -0,0 +1,24 @@
+package tracer
+
+import "testing"
+
+func TestPathParamNormalizer(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"/users/123/posts", "/users/:id/posts"},
+		{"/users/1/posts/2", "/users/:id/posts/:id"},
+		{"/users/abc", "/users/abc"},
+		{"/users/12a", "/users/12a"},
+		{"/", "/"},
+		{"getUser123", "getUser123"},
+		{"123", "123"},
+	}
+	n := PathParamNormalizer()
+	for _, tt := range tests {
+		if got := n(tt.tag); got != tt.want {
+			t.Errorf("PathParamNormalizer()(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
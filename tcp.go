@@ -0,0 +1,52 @@
+package tracer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tcpStateCounts is number of TCP connections per state
+type tcpStateCounts struct {
+	established int64
+	timeWait    int64
+	closeWait   int64
+}
+
+var tcpTrackerOnce sync.Once
+var tcpPeaks tcpStateCounts
+
+func storeMax(addr *int64, v int64) {
+	for {
+		max := atomic.LoadInt64(addr)
+		if v <= max || atomic.CompareAndSwapInt64(addr, max, v) {
+			return
+		}
+	}
+}
+
+// startTCPTracker count TCP connections every second while Tracer is running (Config.TrackTCPConnections)
+func startTCPTracker() {
+	tcpTrackerOnce.Do(func() {
+		go func() {
+			for range time.Tick(time.Second) {
+				if TraceID == "" || !config.TrackTCPConnections {
+					continue
+				}
+				c, ok := readTCPStateCounts()
+				if !ok {
+					continue
+				}
+				storeMax(&tcpPeaks.established, c.established)
+				storeMax(&tcpPeaks.timeWait, c.timeWait)
+				storeMax(&tcpPeaks.closeWait, c.closeWait)
+			}
+		}()
+	})
+}
+
+func resetTCPPeaks() {
+	atomic.StoreInt64(&tcpPeaks.established, 0)
+	atomic.StoreInt64(&tcpPeaks.timeWait, 0)
+	atomic.StoreInt64(&tcpPeaks.closeWait, 0)
+}
@@ -0,0 +1,46 @@
+package tracer
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// TCP states in /proc/net/tcp (include/net/tcp_states.h)
+const (
+	tcpStateEstablished = "01"
+	tcpStateTimeWait    = "06"
+	tcpStateCloseWait   = "08"
+)
+
+// readTCPStateCounts count connections of host in /proc/net/tcp and /proc/net/tcp6
+func readTCPStateCounts() (tcpStateCounts, bool) {
+	var c tcpStateCounts
+	found := false
+	for _, fileName := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(fileName)
+		if err != nil {
+			continue
+		}
+		found = true
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			// sl local_address rem_address st ...
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 4 {
+				continue
+			}
+			switch fields[3] {
+			case tcpStateEstablished:
+				c.established++
+			case tcpStateTimeWait:
+				c.timeWait++
+			case tcpStateCloseWait:
+				c.closeWait++
+			}
+		}
+		file.Close()
+	}
+	return c, found
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package tracer
+
+func readTCPStateCounts() (tcpStateCounts, bool) {
+	return tcpStateCounts{}, false
+}
@@ -0,0 +1,22 @@
+package tracer
+
+import (
+	"regexp"
+	"time"
+)
+
+// ThrottleRule delay SQL queries matching Pattern to simulate slow database (Config.ThrottleRules)
+type ThrottleRule struct {
+	Pattern *regexp.Regexp
+	Delay   time.Duration
+}
+
+// throttleQuery sleep Delay of the first ThrottleRule matching query
+func throttleQuery(query string) {
+	for _, rule := range config.ThrottleRules {
+		if rule.Pattern != nil && rule.Pattern.MatchString(query) {
+			time.Sleep(rule.Delay)
+			return
+		}
+	}
+}
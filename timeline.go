@@ -0,0 +1,149 @@
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const maxLogLineSize = 1024 * 1024
+
+var errNotTSVEncoder = errors.New("log files are readable only with TSVEncoder")
+
+// checkTSVEncoder return error if Config.Encoder is not TSVEncoder (log files can not be parsed as TSV)
+func checkTSVEncoder() error {
+	if _, ok := config.encoder().(TSVEncoder); !ok {
+		return errNotTSVEncoder
+	}
+	return nil
+}
+
+type timelineEntry struct {
+	startTime int64
+	duration  int64
+	source    string
+	tag       string
+	text      string
+}
+
+// readTimelineEntries read start_ns, duration_ns, tag, text columns of log file
+func readTimelineEntries(fileName string, source string) ([]timelineEntry, error) {
+	file, err := OpenLog(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []timelineEntry
+	scanner := NewLogScanner(file)
+	for scanner.Scan() {
+		columns := scanner.Columns()
+		if len(columns) < 4 {
+			continue
+		}
+		startTime, err := strconv.ParseInt(columns[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		duration, err := strconv.ParseInt(columns[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, timelineEntry{startTime: startTime, duration: duration, source: source, tag: columns[2], text: columns[3]})
+	}
+	warnSkippedLines(fileName, scanner)
+	return entries, scanner.Err()
+}
+
+// exportTimeline merge SQL, perf and route logs sorted by start time
+// Columns: start_ns, duration_ns, source (sql/perf/route), tag, text
+// Config.Encoder other than TSVEncoder is not supported and returns error without writing file.
+func exportTimeline(fileName string) error {
+	if err := checkTSVEncoder(); err != nil {
+		return err
+	}
+	var entries []timelineEntry
+	for _, log := range []struct {
+		level    LogLevel
+		fileName string
+		source   string
+	}{
+		{SQLLog, sqlLogFileName, "sql"},
+		{PerfLog, perfomanceLogFileName, "perf"},
+		{RouteLog, webrouteLogFileName, "route"},
+	} {
+		if !config.logEnabled(log.level) {
+			continue
+		}
+		e, err := readTimelineEntries(log.fileName, log.source)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].startTime < entries[j].startTime
+	})
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "# start_ns\tduration_ns\tsource\ttag\ttext\n")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", e.startTime, e.duration, e.source, e.tag, e.text)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadSQLLog read entries of sql.log (header, MARK and corrupted lines are skipped)
+// sql.log must be written by TSVEncoder, error is returned if Config.Encoder is other encoder.
+func ReadSQLLog(fileName string) ([]SQLEntry, error) {
+	if err := checkTSVEncoder(); err != nil {
+		return nil, err
+	}
+	file, err := OpenLog(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []SQLEntry
+	scanner := NewLogScanner(file)
+	for scanner.Scan() {
+		columns := scanner.Columns()
+		if len(columns) < 4 || columns[2] == "MARK" {
+			continue
+		}
+		startTime, err := strconv.ParseInt(columns[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		duration, err := strconv.ParseInt(columns[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		e := SQLEntry{StartTime: startTime, Duration: time.Duration(duration), Tag: columns[2], Query: columns[3]}
+		if len(columns) > 4 {
+			e.PerfTag = columns[4]
+		}
+		if len(columns) > 5 {
+			e.Cacheable = columns[5] == "true"
+		}
+		if len(columns) > 6 {
+			e.CPUID = columns[6]
+		}
+		entries = append(entries, e)
+	}
+	warnSkippedLines(fileName, scanner)
+	return entries, scanner.Err()
+}
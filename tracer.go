@@ -4,17 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"os/signal"
 	"path"
 	"regexp"
+	"runtime"
 	"strings"
-	"syscall"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/pkg/profile"
 	proxy "github.com/shogo82148/go-sql-proxy"
 )
 
@@ -27,154 +29,631 @@ var perfomanceLogFileName string
 var perfomanceLogFile *os.File
 var webrouteLogFileName string
 var webrouteLogFile *os.File
-var profilerHandle interface{ Stop() }
 
 // PerfHandle is Perfomance Measure Handle
 type PerfHandle struct {
-	startTime int64
-	tag       string
-	text      string
-	toFile    *os.File
+	id            uint64
+	startTime     int64
+	tag           string
+	text          string
+	route         bool
+	cacheable     bool
+	cacheHit      bool
+	duplicate     bool
+	path          []string
+	caller        string
+	cpu           cpuSample
+	gid           int64         // goroutine ID of MeasureContext (Config.GoroutineLocalContext)
+	prevPath      []string      // perf path of the goroutine before MeasureContext
+	writeDuration time.Duration // time blocked in ResponseWriter.Write (Config.MeasureResponseBuffering)
+	requestTags   []string      // labels added by TagRequest (Middleware only)
+	concurrency   string        // tag of route concurrency counter (tag on start)
+	params        string        // path parameters of router (SetParams)
+	toFile        *os.File
 }
 
 // End is Function called when Perfomance Measure End
 func (p *PerfHandle) End() {
+	p.release()
 	if p.toFile != nil {
-		timeDelta := time.Now().UnixNano() - p.startTime
-		fmt.Fprintf(p.toFile, "%d\t%d\t%s\t%s\n", p.startTime, timeDelta, p.tag, p.text)
+		timeDelta := time.Duration(now().UnixNano() - p.startTime)
+		p.tag = normalizeTag(p.tag)
+		addTagStat(p.tag, timeDelta)
+		if p.route {
+			writeRoute(p.toFile, RouteEntry{StartTime: p.startTime, Duration: timeDelta, Tag: p.tag, Text: p.text, Cacheable: p.cacheable, CacheHit: p.cacheHit, Duplicate: p.duplicate, WriteDuration: p.writeDuration, Tags: p.requestTags, Params: p.params})
+		} else {
+			e := PerfEntry{StartTime: p.startTime, Duration: timeDelta, Tag: p.tag, Text: p.text, Caller: p.caller}
+			if config.AnnotateCPU {
+				e.CPU = cpuUtilization(p.cpu, readCPUSample())
+			}
+			writePerf(p.toFile, e)
+			if config.HierarchicalPerfLog {
+				addPerfTree(p.path, p.tag, timeDelta)
+			}
+		}
+	}
+}
+
+// release end in-flight, route concurrency and goroutine-local path of measurement
+func (p *PerfHandle) release() {
+	if p.id != 0 {
+		endInFlight(p.id)
+		p.id = 0
+	}
+	if p.concurrency != "" {
+		endRouteConcurrency(p.concurrency)
+		p.concurrency = ""
+	}
+	if p.gid != 0 {
+		popGoroutinePath(p.gid, p.prevPath)
+		p.gid = 0
+	}
+}
+
+// discard release measurement without writing it (e.g. route excluded after routing)
+func (p *PerfHandle) discard() {
+	p.release()
+	p.toFile = nil
+}
+
+// SetTag set tag of measurement (e.g. route pattern resolved while handling request)
+// Route concurrency is moved from the tag on start to the new tag.
+func (p *PerfHandle) SetTag(tag string) {
+	p.tag = tag
+	if p.concurrency != "" {
+		if t := normalizeTag(tag); t != p.concurrency {
+			moveRouteConcurrency(p.concurrency, t)
+			p.concurrency = t
+		}
 	}
 }
 
+// SetStatus set HTTP status code of web route (5xx are counted for Config.EnableAlarms)
+// Used by router integrations which do not use Middleware.
+func (p *PerfHandle) SetStatus(statusCode int) {
+	if p.toFile != nil && statusCode >= http.StatusInternalServerError {
+		countRouteError()
+	}
+}
+
+// SetParams set path parameters of web route written to params column of webroute.log (e.g. "id=1")
+// Used by router integrations (gintracer, chitracer), because route tag is the parameterized path.
+func (p *PerfHandle) SetParams(params string) {
+	p.params = params
+}
+
 // Measure make create New Performance Measure Handle
 func Measure(tag string, text string) PerfHandle {
-	return PerfHandle{startTime: time.Now().UnixNano(), tag: tag, text: text, toFile: perfomanceLogFile}
+	return measure(tag, text)
+}
+
+// measure is called only from Measure and MeasureContext (caller is 2 frames above)
+func measure(tag string, text string) PerfHandle {
+	p := newPerfHandle(tag, text, false, perfomanceLogFile)
+	if config.RecordCaller && p.toFile != nil {
+		p.caller = callerFileLine(3)
+	}
+	if config.AnnotateCPU && p.toFile != nil {
+		p.cpu = readCPUSample()
+	}
+	return p
+}
+
+// callerFileLine return "file:line" of the caller skip frames above
+func callerFileLine(skip int) string {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) == 0 {
+		return ""
+	}
+	fn := runtime.FuncForPC(pcs[0] - 1)
+	if fn == nil {
+		return ""
+	}
+	file, line := fn.FileLine(pcs[0] - 1)
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+func newPerfHandle(tag string, text string, route bool, toFile *os.File) PerfHandle {
+	if toFile != nil && isInFlightOverflow() {
+		return PerfHandle{}
+	}
+	p := PerfHandle{startTime: now().UnixNano(), tag: tag, text: text, route: route, toFile: toFile}
+	if toFile != nil {
+		p.id = startInFlight(p.startTime, tag, text)
+	}
+	return p
+}
+
+type perfPathKey struct{}
+
+// MeasureContext make create New Performance Measure Handle and Context holding its tag
+// SQL queries executed with the returned Context write the tag to perf_tag column of sql.log.
+// Measurements created from the returned Context become children of this measurement.
+// With Config.GoroutineLocalContext, the tag is also stored per goroutine until End,
+// and SQL queries or MeasureContext on the same goroutine without the Context find it.
+//
+//	ctx, p := tracer.MeasureContext(ctx, "getUser", "")
+//	defer p.End()
+//	db.QueryRowContext(ctx, "SELECT ...")
+func MeasureContext(ctx context.Context, tag string, text string) (context.Context, PerfHandle) {
+	parentPath := perfPathFromContext(ctx)
+	if parentPath == nil {
+		parentPath = goroutinePath()
+	}
+	path := make([]string, len(parentPath), len(parentPath)+1)
+	copy(path, parentPath)
+	path = append(path, normalizeTag(tag))
+	p := measure(tag, text)
+	p.path = path
+	if config.GoroutineLocalContext {
+		p.gid, p.prevPath = pushGoroutinePath(path)
+	}
+	return context.WithValue(ctx, perfPathKey{}, path), p
+}
+
+// WithSQLGroup return Context with group written to perf_tag column of sql.log by SQL queries executed with it
+// Unlike MeasureContext, nothing is measured (e.g. route name set by router integrations).
+func WithSQLGroup(ctx context.Context, group string) context.Context {
+	parentPath := perfPathFromContext(ctx)
+	path := make([]string, len(parentPath), len(parentPath)+1)
+	copy(path, parentPath)
+	return context.WithValue(ctx, perfPathKey{}, append(path, normalizeTag(group)))
+}
+
+func perfPathFromContext(ctx context.Context) []string {
+	path, _ := ctx.Value(perfPathKey{}).([]string)
+	return path
+}
+
+func perfTagFromContext(ctx context.Context) string {
+	path := perfPathFromContext(ctx)
+	if path == nil {
+		path = goroutinePath()
+	}
+	if len(path) == 0 {
+		return ""
+	}
+	return path[len(path)-1]
 }
 
 // WebRouteMeasure make create New Web Route Performance Measure Handle
 func WebRouteMeasure(tag string, text string) PerfHandle {
-	return PerfHandle{startTime: time.Now().UnixNano(), tag: tag, text: text, toFile: webrouteLogFile}
+	if excludedRoutes[tag] {
+		return PerfHandle{}
+	}
+	countRequest()
+	p := newPerfHandle(tag, text, true, webrouteLogFile)
+	if p.toFile != nil {
+		p.concurrency = normalizeTag(tag)
+		startRouteConcurrency(p.concurrency)
+	}
+	return p
 }
 
 // Initialize ISUCON Tracer
-// Wait signal (USR1, USR2, HUP, INT, TERM, QUIT)
+// Wait signal (USR1, USR2, HUP, INT, TERM, QUIT, or Config.StartSignal, StopSignal and RotateSignal)
 func init() {
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	notifySignals()
 	go func() {
 		for {
 			signal := <-signalCh
 			log.Printf("ISUCON Tracer Catch Signal (%s)\n", signal)
-			if signal == syscall.SIGUSR1 {
-				Start()
-			} else if signal == syscall.SIGHUP || signal == syscall.SIGUSR2 {
-				Stop()
-			} else {
-				Stop()
-				os.Exit(0)
-			}
+			handleSignal(signal)
 		}
 	}()
 
 	registerTraceDBDriver()
 }
 
-func registerTraceDBDriver() {
-	regexCutSpace := regexp.MustCompile(`[ \r\n\t]{1,}`)
-	regexTagComment := regexp.MustCompile(`(/\* *(.*?) *\*/)`)
+// traceStarted is 1 after Start is called once
+var traceStarted int32
+
+// stopAndExit Stop Tracer and exit
+// Exit with status 1 if Stop does not finish within Config.ExitTimeout
+func stopAndExit() {
+	if stopWithTimeout() {
+		os.Exit(0)
+	}
+	os.Exit(1)
+}
+
+// stopWithTimeout Stop Tracer, and report whether Stop finished within Config.ExitTimeout
+func stopWithTimeout() bool {
+	if atomic.LoadInt32(&traceStarted) == 0 {
+		log.Printf("ISUCON Tracer Warning: Start was never called, no log files are written (call tracer.Start() or send SIGUSR1 to pid %d)\n", os.Getpid())
+	}
+	done := make(chan struct{})
+	go func() {
+		logError(Stop())
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(config.exitTimeout()):
+		log.Printf("ISUCON Tracer Exit Timeout (%s)\n", config.exitTimeout())
+		return false
+	}
+}
 
+var regexCutSpace = regexp.MustCompile(`[ \r\n\t]{1,}`)
+var regexTagComment = regexp.MustCompile(`(/\* *(.*?) *\*/)`)
+
+// sqlStart is passed from PreFunc to PostFunc of SQL proxy
+type sqlStart struct {
+	startTime int64
+	cpuID     string // Config.TrackCPUAffinity
+}
+
+func newTraceDBDriver(d driver.Driver) driver.Driver {
 	PreFunc := func(c context.Context, stmt *proxy.Stmt, args []driver.NamedValue) (interface{}, error) {
-		return time.Now().UnixNano(), nil
+		start := sqlStart{startTime: now().UnixNano()}
+		if config.TrackCPUAffinity {
+			start.cpuID = currentCPU()
+		}
+		startSQLInFlight()
+		if len(config.ThrottleRules) > 0 {
+			throttleQuery(stmt.QueryString)
+		}
+		return start, nil
 	}
 	PostFunc := func(c context.Context, ctx interface{}, stmt *proxy.Stmt, args []driver.NamedValue, err error) error {
+		endSQLInFlight()
 		if sqlLogFile != nil && err != driver.ErrSkip {
-			now := time.Now()
-			startTime := ctx.(int64)
-			timeDelta := now.UnixNano() - startTime
+			start := ctx.(sqlStart)
+			startTime := start.startTime
+			timeDelta := now().UnixNano() - startTime
 			query := regexCutSpace.ReplaceAllString(stmt.QueryString, " ")
 			posList := regexTagComment.FindStringSubmatchIndex(query)
 			tag := ""
 			if posList != nil {
-				tag = query[posList[4]:posList[5]]
+				tag = normalizeTag(query[posList[4]:posList[5]])
 				query = query[:posList[1]]
 			}
-			fmt.Fprintf(sqlLogFile, "%d\t%d\t%s\t%s\n", startTime, timeDelta, tag, query)
+			cacheable := false
+			if config.TrackCacheableQueries && err == nil {
+				cacheable = checkCacheableQuery(query, args)
+			}
+			if isBlacklistedQuery(query) {
+				query = "[REDACTED]"
+			}
+			writeSQL(sqlLogFile, SQLEntry{StartTime: startTime, Duration: time.Duration(timeDelta), Tag: tag, Query: query, PerfTag: perfTagFromContext(c), Cacheable: cacheable, CPUID: start.cpuID})
+			checkQueryBudget(c)
+			addServerTiming(c, time.Duration(timeDelta))
+			if config.TrackHostLatency {
+				addHostStat(stmt.Conn, time.Duration(timeDelta), err)
+			}
 		}
 		return nil
 	}
 
+	return proxy.NewProxyContext(&dryRunDriver{base: d}, &proxy.HooksContext{
+		PreExec: PreFunc,
+		PostExec: func(c context.Context, ctx interface{}, stmt *proxy.Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			return PostFunc(c, ctx, stmt, args, err)
+		},
+		PreQuery: PreFunc,
+		PostQuery: func(c context.Context, ctx interface{}, stmt *proxy.Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			return PostFunc(c, ctx, stmt, args, err)
+		},
+		PreOpen: func(c context.Context, name string) (interface{}, error) {
+			return dsnHost(name), nil
+		},
+		PostOpen: func(c context.Context, ctx interface{}, conn *proxy.Conn, err error) error {
+			if err == nil {
+				connHosts.Store(conn, ctx)
+			}
+			return nil
+		},
+		PostClose: func(c context.Context, ctx interface{}, conn *proxy.Conn, err error) error {
+			connHosts.Delete(conn)
+			return nil
+		},
+	})
+}
+
+var registerDriverMutex sync.Mutex
+var wrapNewDriversOnce sync.Once
+
+const wrapNewDriversInterval = time.Second
+
+func isBlacklistedQuery(query string) bool {
+	for _, re := range config.QueryBlacklist {
+		if re.MatchString(query) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDriverRegistered(driverName string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driverName {
+			return true
+		}
+	}
+	return false
+}
+
+// registerProxyDriver register driverName + ":logger" driver if not registered yet
+func registerProxyDriver(driverName string, dsn string) {
+	registerDriverMutex.Lock()
+	defer registerDriverMutex.Unlock()
+	newDriverName := driverName + ":logger"
+	if isDriverRegistered(newDriverName) {
+		return
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
+		return
+	}
+	defer db.Close()
+	log.Printf("ISUCON Tracer SQL Driver Register: %s\n", newDriverName)
+	sql.Register(newDriverName, newTraceDBDriver(db.Driver()))
+}
+
+func registerTraceDBDriver() {
 	for _, driverName := range sql.Drivers() {
 		if strings.Contains(driverName, ":logger") {
 			continue
 		}
-		db, _ := sql.Open(driverName, "")
-		defer db.Close()
-		newDriverName := driverName + ":logger"
-		log.Printf("ISUCON Tracer SQL Driver Register: %s\n", newDriverName)
-		sql.Register(driverName+":logger", proxy.NewProxyContext(db.Driver(), &proxy.HooksContext{
-			PreExec: PreFunc,
-			PostExec: func(c context.Context, ctx interface{}, stmt *proxy.Stmt, args []driver.NamedValue, result driver.Result, err error) error {
-				return PostFunc(c, ctx, stmt, args, err)
-			},
-			PreQuery: PreFunc,
-			PostQuery: func(c context.Context, ctx interface{}, stmt *proxy.Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
-				return PostFunc(c, ctx, stmt, args, err)
-			},
-		}))
+		registerProxyDriver(driverName, "")
 	}
 }
 
+// RegisterSQLiteDriver register SQLite Driver as driverName + ":logger"
+// SQLite driver is opened with ":memory:" DSN instead of empty DSN.
+// Use this function to trace SQLite, because SQLite driver is usually registered after this package init.
+//
+//	import _ "github.com/mattn/go-sqlite3"
+//	tracer.RegisterSQLiteDriver("sqlite3")
+//	db, err := sql.Open("sqlite3:logger", "app.db")
+func RegisterSQLiteDriver(driverName string) {
+	registerProxyDriver(driverName, ":memory:")
+}
+
+// WrapNewDrivers register ":logger" drivers for drivers registered after this package init
+// Drivers are checked immediately, and then polled every second in background.
+//
+//	sql.Register("sqlite3_custom", &sqlite3.SQLiteDriver{ConnectHook: ...})
+//	tracer.WrapNewDrivers()
+//	db, err := sql.Open("sqlite3_custom:logger", "app.db")
+func WrapNewDrivers() {
+	registerTraceDBDriver()
+	wrapNewDriversOnce.Do(func() {
+		go func() {
+			for range time.Tick(wrapNewDriversInterval) {
+				registerTraceDBDriver()
+			}
+		}()
+	})
+}
+
+// traceMutex serialize Start, Stop and Checkpoint called from signals, timers and handlers
+var traceMutex sync.Mutex
+
 // Start ISUCON Tracer Start
-func Start() {
+// Stop is called first if Tracer is already running
+func Start() error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	return start(config.traceID(), now())
+}
+
+// StartAt is Start with backdated start time
+// TraceID is made from t (Config.TraceIDGenerator is not used), and summary start time is t.
+func StartAt(t time.Time) error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	return start(t.Format(traceIDLayout), t)
+}
+
+// start is called with traceMutex held
+func start(traceID string, startTime time.Time) error {
 
 	var err error
 
 	if TraceID != "" {
-		Stop()
+		if err = stop(); err != nil {
+			return err
+		}
 	}
 
-	const tmpDirName = "/tmp"
+	if config.AutoDetectRAMDisk && config.LogDir == "" {
+		ramDiskDir = detectRAMDisk()
+	}
+	logDirName := config.logDir()
 
-	TraceID = time.Now().Format("20060102-150405")
+	TraceID = traceID
+	atomic.StoreInt32(&traceStarted, 1)
 	log.Printf("ISUCON Tracer Start (%s)\n", TraceID)
+	resetQueryCounts()
+	resetCacheCounts()
+	resetPerfTree()
+	resetTagStats()
+	resetHostStats()
+	resetRouteWindows()
+	resetOverflowCount()
+	resetCacheableQueries()
+	resetSQLInFlightPeak()
+	resetHTTPClientCounts()
+	resetRecent()
+	resetRouteConcurrencies()
+	resetHeatmap()
+	resetSummary(startTime)
+
+	// Stop automatically after Config.MaxTraceDuration
+	startTraceTimer(TraceID)
+
+	// Start Periodic Reporter
+	startReporter()
+
+	// Memory Only Mode (no files are created)
+	if config.MemoryOnly {
+		return openMemoryOnlyLogFiles()
+	}
 
 	// Start Profiler
-	profilerHandle = profile.Start(profile.ProfilePath(tmpDirName), profile.NoShutdownHook)
+	startProfiler(logDirName)
+	startMutexProfile()
+	startGoroutineSampler()
+
+	// Start Anomaly Detectors
+	if err = startAlarms(TraceID, logDirName); err != nil {
+		return err
+	}
+
+	// Start Connection Pool Observer
+	if err = startDBPoolObserver(TraceID, logDirName); err != nil {
+		return err
+	}
 
 	// Create SQL Log File
-	sqlLogFileName = path.Join(tmpDirName, "sql.log")
-	if sqlLogFile, err = os.Create(sqlLogFileName); err != nil {
-		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
-		return
+	sqlLogFile = nil
+	if config.logEnabled(SQLLog) {
+		sqlLogFileName = compressedPath(config.logPath(config.SQLLogPath, "sql"))
+		if sqlLogFile, err = createLog(sqlLogFileName); err != nil {
+			return err
+		}
+		encodeHeader(logWriter(sqlLogFile), sqlLogColumns)
 	}
 
 	// Create Perfomance Log File
-	perfomanceLogFileName = path.Join(tmpDirName, "perf.log")
-	if perfomanceLogFile, err = os.Create(perfomanceLogFileName); err != nil {
-		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
-		return
+	perfomanceLogFile = nil
+	if config.logEnabled(PerfLog) {
+		perfomanceLogFileName = compressedPath(config.logPath(config.PerfLogPath, "perf"))
+		if perfomanceLogFile, err = createLog(perfomanceLogFileName); err != nil {
+			return err
+		}
+		encodeHeader(logWriter(perfomanceLogFile), perfLogColumns)
 	}
 
 	// Create Webroute Log File
-	webrouteLogFileName = path.Join(tmpDirName, "webroute.log")
-	if webrouteLogFile, err = os.Create(webrouteLogFileName); err != nil {
-		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
-		return
+	webrouteLogFile = nil
+	if config.logEnabled(RouteLog) {
+		webrouteLogFileName = compressedPath(config.logPath(config.RouteLogPath, "webroute"))
+		if webrouteLogFile, err = createLog(webrouteLogFileName); err != nil {
+			return err
+		}
+		encodeHeader(logWriter(webrouteLogFile), routeLogColumns)
 	}
+
+	// Create Additional Log Files
+	if err = createLogFiles(logDirName); err != nil {
+		return err
+	}
+
+	// Count open files after files of Tracer are opened
+	resetFDCount()
+	return nil
 }
 
 // Stop ISUCON Tracer Stop
-func Stop() {
+// Files are closed even if error occurs, and the errors are joined
+func Stop() error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	return stop()
+}
+
+// stopTrace Stop Tracer if the trace of traceID is still running (timers of the trace)
+func stopTrace(traceID string) error {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if TraceID != traceID {
+		return nil
+	}
+	return stop()
+}
+
+// stop is called with traceMutex held
+func stop() error {
+	var errs []error
+	traceID := TraceID
 	if TraceID != "" {
 		log.Printf("ISUCON Tracer End (%s)\n", TraceID)
+		if err := flushSinks(); err != nil {
+			errs = append(errs, err)
+		}
+		if config.logEnabled(SummaryLog) && !config.MemoryOnly {
+			if err := writeSummary(config.summaryPath()); err != nil {
+				errs = append(errs, err)
+			}
+		}
 		TraceID = ""
 	}
-	if profilerHandle != nil {
-		profilerHandle.Stop()
+	stopTraceTimer()
+	stopReporter()
+	stopProfiler()
+	if err := stopAlarms(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := stopDBPoolObserver(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := stopMutexProfile(config.logDir()); err != nil {
+		errs = append(errs, err)
+	}
+	if err := stopGoroutineSampler(path.Join(config.logDir(), "goroutines-peak-"+traceID+".txt")); err != nil {
+		errs = append(errs, err)
 	}
 	if sqlLogFile != nil {
-		sqlLogFile.Close()
+		if err := closeLog(sqlLogFile); err != nil {
+			errs = append(errs, err)
+		}
 	}
 	if perfomanceLogFile != nil {
-		perfomanceLogFile.Close()
+		if err := closeLog(perfomanceLogFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if webrouteLogFile != nil {
+		if err := closeLog(webrouteLogFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	closeLogFiles()
+	writeFiles := traceID != "" && !config.MemoryOnly
+	if writeFiles && config.HierarchicalPerfLog {
+		if err := writePerfTree(path.Join(config.logDir(), "perf-tree-"+traceID+".txt")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if writeFiles && config.TrackCacheableQueries {
+		if err := writeCacheableQueries(path.Join(config.logDir(), "cacheable_queries.log")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if writeFiles && config.HeatmapExport {
+		if err := writeHeatmap(path.Join(config.logDir(), "heatmap-"+traceID+".json")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if writeFiles && config.ExportTimeline {
+		if err := exportTimeline(path.Join(config.logDir(), "timeline-"+traceID+".tsv")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MustStart is Start which panics on error
+func MustStart() {
+	if err := Start(); err != nil {
+		panic(err)
+	}
+}
+
+// MustStop is Stop which panics on error
+func MustStop() {
+	if err := Stop(); err != nil {
+		panic(err)
+	}
+}
+
+func logError(err error) {
+	if err != nil {
+		log.Printf("ISUCON Tracer Error: %s\n", err.Error())
 	}
 }
@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type webSocketStartKey struct{}
+
+// isWebSocketUpgrade report whether request has "Connection: Upgrade" and "Upgrade: websocket" headers
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func requestRouteTag(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// webSocketWriter End handshake measurement when the connection is hijacked by WebSocket upgrader
+type webSocketWriter struct {
+	http.ResponseWriter
+	r         *http.Request
+	handshake PerfHandle
+}
+
+// Hijack implements http.Hijacker
+func (w *webSocketWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.handshake.tag = "ws_handshake:" + requestRouteTag(w.r)
+		w.handshake.End()
+	}
+	return conn, rw, err
+}
+
+// discardHandshake release handshake measurement if the connection was not hijacked (upgrade rejected)
+// After End by Hijack, the handle is already released and this does nothing.
+func (w *webSocketWriter) discardHandshake() {
+	w.handshake.discard()
+}
+
+// Unwrap is used by http.ResponseController
+func (w *webSocketWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// webSocketConn write lifetime of WebSocket connection to webroute.log on Close
+type webSocketConn struct {
+	net.Conn
+	tag       string
+	text      string
+	startTime int64
+	once      sync.Once
+}
+
+func (c *webSocketConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		p := PerfHandle{startTime: c.startTime, tag: c.tag, text: c.text, route: true, toFile: webrouteLogFile}
+		p.End()
+	})
+	return err
+}
+
+// WrapWebSocketConn wrap upgraded WebSocket connection to write its lifetime to webroute.log on Close
+// Tag is "ws:" + route tag, and the lifetime starts at the handshake start if the request is measured by Middleware.
+//
+//	conn, err := upgrader.Upgrade(w, r, nil)
+//	nc := tracer.WrapWebSocketConn(r, conn.NetConn())
+//	defer nc.Close()
+func WrapWebSocketConn(r *http.Request, conn net.Conn) net.Conn {
+	if TraceID == "" {
+		return conn
+	}
+	startTime, ok := r.Context().Value(webSocketStartKey{}).(int64)
+	if !ok {
+		startTime = now().UnixNano()
+	}
+	return &webSocketConn{Conn: conn, tag: "ws:" + requestRouteTag(r), text: r.Method, startTime: startTime}
+}
+
+// withWebSocketHandshake measure WebSocket handshake of request until the connection is hijacked
+// Call discardHandshake of returned writer (nil if not measured) after the handler returns.
+func withWebSocketHandshake(w http.ResponseWriter, r *http.Request) (*webSocketWriter, *http.Request) {
+	handshake := newPerfHandle("ws_handshake:"+r.URL.Path, r.Method, true, webrouteLogFile)
+	if handshake.toFile == nil {
+		return nil, r
+	}
+	r = r.WithContext(context.WithValue(r.Context(), webSocketStartKey{}, handshake.startTime))
+	return &webSocketWriter{ResponseWriter: w, r: r, handshake: handshake}, r
+}
@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// routeWindowSize is number of recent measurements kept per route tag
+const routeWindowSize = 1000
+
+// SlidingWindow keeps last N durations in insertion order and in sorted order for rolling percentiles
+type SlidingWindow struct {
+	mutex  sync.Mutex
+	size   int
+	ring   []time.Duration
+	next   int
+	sorted []time.Duration
+}
+
+// NewSlidingWindow make create New SlidingWindow of last size durations (size is at least 1)
+func NewSlidingWindow(size int) *SlidingWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &SlidingWindow{size: size, ring: make([]time.Duration, 0, size), sorted: make([]time.Duration, 0, size)}
+}
+
+// Add add duration (the oldest one is removed if window is full)
+func (w *SlidingWindow) Add(d time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.ring) < w.size {
+		w.ring = append(w.ring, d)
+	} else {
+		old := w.ring[w.next]
+		w.ring[w.next] = d
+		w.next = (w.next + 1) % w.size
+		i := sort.Search(len(w.sorted), func(i int) bool { return w.sorted[i] >= old })
+		w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+	}
+	i := sort.Search(len(w.sorted), func(i int) bool { return w.sorted[i] > d })
+	w.sorted = append(w.sorted, 0)
+	copy(w.sorted[i+1:], w.sorted[i:])
+	w.sorted[i] = d
+}
+
+// Len return number of durations in window
+func (w *SlidingWindow) Len() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.sorted)
+}
+
+// Quantile return q-quantile (0 < q <= 1) of durations in window (0 if empty)
+func (w *SlidingWindow) Quantile(q float64) time.Duration {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(w.sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return w.sorted[i]
+}
+
+// Median return median of durations in window
+func (w *SlidingWindow) Median() time.Duration {
+	return w.Quantile(0.5)
+}
+
+// P95 return 95th percentile of durations in window
+func (w *SlidingWindow) P95() time.Duration {
+	return w.Quantile(0.95)
+}
+
+// P99 return 99th percentile of durations in window
+func (w *SlidingWindow) P99() time.Duration {
+	return w.Quantile(0.99)
+}
+
+var routeWindows sync.Map
+
+func addRouteWindow(e RouteEntry) {
+	v, ok := routeWindows.Load(e.Tag)
+	if !ok {
+		v, _ = routeWindows.LoadOrStore(e.Tag, NewSlidingWindow(routeWindowSize))
+	}
+	v.(*SlidingWindow).Add(e.Duration)
+}
+
+func resetRouteWindows() {
+	routeWindows.Range(func(key, value interface{}) bool {
+		routeWindows.Delete(key)
+		return true
+	})
+}
+
+// RouteWindow return SlidingWindow of last 1000 measurements of route tag (nil if not measured)
+func RouteWindow(tag string) *SlidingWindow {
+	if v, ok := routeWindows.Load(tag); ok {
+		return v.(*SlidingWindow)
+	}
+	return nil
+}
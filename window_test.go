@@ -0,0 +1,40 @@
+package tracer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	w := NewSlidingWindow(3)
+	if w.Median() != 0 {
+		t.Errorf("Median() of empty window = %v, want 0", w.Median())
+	}
+	for _, d := range []time.Duration{5, 1, 3, 4} {
+		w.Add(d)
+	}
+	// 5 is removed as the oldest
+	if w.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", w.Len())
+	}
+	if got := w.Quantile(0.01); got != 1 {
+		t.Errorf("Quantile(0.01) = %v, want 1", got)
+	}
+	if got := w.Median(); got != 3 {
+		t.Errorf("Median() = %v, want 3", got)
+	}
+	if got := w.P99(); got != 4 {
+		t.Errorf("P99() = %v, want 4", got)
+	}
+}
+
+func TestNewSlidingWindowNonPositiveSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		w := NewSlidingWindow(size)
+		w.Add(1)
+		w.Add(2)
+		if w.Len() != 1 || w.Median() != 2 {
+			t.Errorf("NewSlidingWindow(%d): Len() = %d, Median() = %v, want 1, 2", size, w.Len(), w.Median())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package zaptracer is zap Sink for ISUCON Tracer
+//
+//	tracer.SetConfig(tracer.Config{Sinks: []tracer.Sink{zaptracer.ZapSink(logger)}})
+package zaptracer
+
+import (
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"go.uber.org/zap"
+)
+
+type zapSink struct {
+	logger *zap.Logger
+}
+
+// ZapSink make create New Sink writing measurements to zap.Logger (Debug level)
+func ZapSink(logger *zap.Logger) tracer.Sink {
+	return &zapSink{logger: logger}
+}
+
+func (s *zapSink) WriteSQL(e tracer.SQLEntry) {
+	s.logger.Debug("sql",
+		zap.String("trace_id", tracer.TraceID),
+		zap.Time("start", time.Unix(0, e.StartTime)),
+		zap.Duration("duration", e.Duration),
+		zap.String("tag", e.Tag),
+		zap.String("query", e.Query),
+	)
+}
+
+func (s *zapSink) WritePerf(e tracer.PerfEntry) {
+	s.logger.Debug("perf",
+		zap.String("trace_id", tracer.TraceID),
+		zap.Time("start", time.Unix(0, e.StartTime)),
+		zap.Duration("duration", e.Duration),
+		zap.String("tag", e.Tag),
+		zap.String("text", e.Text),
+	)
+}
+
+func (s *zapSink) WriteRoute(e tracer.RouteEntry) {
+	s.logger.Debug("route",
+		zap.String("trace_id", tracer.TraceID),
+		zap.Time("start", time.Unix(0, e.StartTime)),
+		zap.Duration("duration", e.Duration),
+		zap.String("tag", e.Tag),
+		zap.String("text", e.Text),
+	)
+}
@@ -0,0 +1,50 @@
+// Package zerologtracer is zerolog Sink for ISUCON Tracer
+//
+//	tracer.SetConfig(tracer.Config{Sinks: []tracer.Sink{zerologtracer.ZerologSink(log.Logger)}})
+package zerologtracer
+
+import (
+	"time"
+
+	tracer "github.com/hirosuzuki/go-isucon-tracer"
+	"github.com/rs/zerolog"
+)
+
+type zerologSink struct {
+	logger zerolog.Logger
+}
+
+// ZerologSink make create New Sink writing measurements to zerolog.Logger
+func ZerologSink(logger zerolog.Logger) tracer.Sink {
+	return &zerologSink{logger: logger}
+}
+
+func (s *zerologSink) WriteSQL(e tracer.SQLEntry) {
+	s.logger.Info().
+		Str("trace_id", tracer.TraceID).
+		Time("start", time.Unix(0, e.StartTime)).
+		Dur("duration", e.Duration).
+		Str("tag", e.Tag).
+		Str("query", e.Query).
+		Msg("sql")
+}
+
+func (s *zerologSink) WritePerf(e tracer.PerfEntry) {
+	s.logger.Info().
+		Str("trace_id", tracer.TraceID).
+		Time("start", time.Unix(0, e.StartTime)).
+		Dur("duration", e.Duration).
+		Str("tag", e.Tag).
+		Str("text", e.Text).
+		Msg("perf")
+}
+
+func (s *zerologSink) WriteRoute(e tracer.RouteEntry) {
+	s.logger.Info().
+		Str("trace_id", tracer.TraceID).
+		Time("start", time.Unix(0, e.StartTime)).
+		Dur("duration", e.Duration).
+		Str("tag", e.Tag).
+		Str("text", e.Text).
+		Msg("route")
+}